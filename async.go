@@ -0,0 +1,143 @@
+package cypher
+
+import (
+	"context"
+	"sync"
+)
+
+// A statement and its parameters.
+type Statement struct {
+	Cypher string
+	Params map[string]interface{}
+}
+
+// Future is the eventual result of a statement run in the background.
+type Future struct {
+	done   chan struct{}
+	result Result
+	err    error
+}
+
+// Run the statement on the runner in a new goroutine.
+// The result is fully collected in the background, so the returned Future can be awaited from any goroutine.
+func RunAsync(runner Runner, cypher string, params map[string]interface{}) *Future {
+	f := &Future{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		f.result, f.err = buffer(runner.Run(cypher, params))
+	}()
+	return f
+}
+
+// Wait for the result of the statement, or until the context is done.
+// The returned result has been fully read from the database, and may be consumed at leisure.
+// If the context is done first, its error is returned and the statement continues to run in the background.
+func (f *Future) Await(ctx context.Context) (Result, error) {
+	select {
+	case <-f.done:
+		return f.result, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Returns a channel which is closed once the result is available.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Run the statements in parallel, running at most limit at a time, or all at once if limit <= 0.
+// The results and errors are returned in the order of the statements.
+// Once the context is done, statements which have not started are not run and report the context's error.
+func RunAll(ctx context.Context, runner Runner, limit int, statements ...Statement) ([]Result, []error) {
+	results := make([]Result, len(statements))
+	errs := make([]error, len(statements))
+	if limit <= 0 || limit > len(statements) {
+		limit = len(statements)
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, s := range statements {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for j := i; j < len(statements); j++ {
+				errs[j] = ctx.Err()
+			}
+			wg.Wait()
+			return results, errs
+		}
+		wg.Add(1)
+		go func(i int, s Statement) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = buffer(runner.Run(s.Cypher, s.Params))
+		}(i, s)
+	}
+	wg.Wait()
+	return results, errs
+}
+
+// Read a result completely, returning a result which replays its rows.
+func buffer(result Result) (Result, error) {
+	rows, err := Collect(result)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := result.Consume()
+	if err != nil {
+		return nil, err
+	}
+	return &bufferedResult{
+		index:   result.Index(),
+		columns: result.Columns(),
+		rows:    rows,
+		stats:   stats,
+		graph:   result.Graph(),
+	}, nil
+}
+
+type bufferedResult struct {
+	index   int
+	columns []string
+	rows    []Row
+	stats   Stats
+	graph   *Graph
+	next    int
+}
+
+func (r *bufferedResult) Index() int {
+	return r.index
+}
+
+func (r *bufferedResult) Columns() []string {
+	return r.columns
+}
+
+func (r *bufferedResult) NextRow() bool {
+	if r.next >= len(r.rows) {
+		return false
+	}
+	r.next++
+	return true
+}
+
+func (r *bufferedResult) GetRow() Row {
+	if r.next == 0 {
+		return nil
+	}
+	return r.rows[r.next-1]
+}
+
+func (r *bufferedResult) Err() error {
+	return nil
+}
+
+func (r *bufferedResult) Consume() (Stats, error) {
+	r.next = len(r.rows)
+	return r.stats, nil
+}
+
+func (r *bufferedResult) Graph() *Graph {
+	return r.graph
+}
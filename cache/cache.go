@@ -0,0 +1,308 @@
+// Package cache wraps a cypher.DB with a cache of fully collected results,
+// for read-heavy workloads which run identical statements with identical parameters.
+//
+// Caching is opt-in per query through DB.Cached. Cached results are invalidated when they expire,
+// when the cache is full and they are the least recently used, or when a statement run through the wrapper
+// reports updates via Stats.ContainsUpdates and references a label which the cached query depends on.
+// Updates from statements which may touch nodes or relationships without naming their labels invalidate
+// every cached result: statements with unlabeled nodes or untyped relationships in their patterns,
+// or which call procedures or subqueries, or detach delete.
+// Updates made in a transaction invalidate results once the transaction is committed.
+package cache
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Options struct {
+	// How long results are cached for when a query does not specify its own TTL. Defaults to one minute.
+	TTL time.Duration
+	// The maximum number of cached results. Defaults to 1000.
+	MaxEntries int
+}
+
+// DB is a cypher.DB which can cache the results of queries.
+type DB struct {
+	cypher.DB
+	ttl   time.Duration
+	cache *lru
+	now   func() time.Time
+}
+
+func New(db cypher.DB, opts Options) *DB {
+	if opts.TTL <= 0 {
+		opts.TTL = time.Minute
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 1000
+	}
+	return &DB{DB: db, ttl: opts.TTL, cache: newLRU(opts.MaxEntries), now: time.Now}
+}
+
+// Returns a runner whose Run results are cached for the given TTL, or the default TTL if 0.
+// The labels are those which the queries depend on: updates to any of them invalidate the cached results.
+// If no labels are given, any update invalidates the cached results.
+func (db *DB) Cached(ttl time.Duration, labels ...string) cypher.Runner {
+	if ttl <= 0 {
+		ttl = db.ttl
+	}
+	return &cachedRunner{db: db, ttl: ttl, labels: labels}
+}
+
+// Remove the cached results which depend on any of the labels, or all cached results if no labels are given.
+func (db *DB) Invalidate(labels ...string) {
+	set := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		set[label] = true
+	}
+	db.cache.invalidate(set)
+}
+
+// Remove all cached results.
+func (db *DB) Purge() {
+	db.cache.purge()
+}
+
+// The number of cached results.
+func (db *DB) Len() int {
+	return db.cache.len()
+}
+
+func (db *DB) Run(statement string, params map[string]interface{}) cypher.Result {
+	return &writeResult{
+		Result:   db.DB.Run(statement, params),
+		labels:   labelsOf(statement),
+		onUpdate: db.cache.invalidate,
+	}
+}
+
+// Run a statement with options, if the wrapped DB accepts them.
+func (db *DB) RunWithOptions(opts cypher.RunOptions, statement string, params map[string]interface{}) cypher.Result {
+	return &writeResult{
+		Result:   cypher.RunWith(db.DB, opts, statement, params),
+		labels:   labelsOf(statement),
+		onUpdate: db.cache.invalidate,
+	}
+}
+
+func (db *DB) RunMany(cypherOrParams ...interface{}) cypher.Response {
+	return &writeResponse{
+		Response:   db.DB.RunMany(cypherOrParams...),
+		statements: statementsOf(cypherOrParams),
+		onUpdate:   db.cache.invalidate,
+	}
+}
+
+func (db *DB) TX() (cypher.Transaction, error) {
+	tx, err := db.DB.TX()
+	if err != nil {
+		return nil, err
+	}
+	return &transaction{Transaction: tx, db: db, updated: make(map[string]bool)}, nil
+}
+
+func (db *DB) TXJob(job func(tx cypher.Transaction) (interface{}, error)) (interface{}, error) {
+	var wrapped *transaction
+	val, err := db.DB.TXJob(func(tx cypher.Transaction) (interface{}, error) {
+		wrapped = &transaction{Transaction: tx, db: db, updated: make(map[string]bool)}
+		return job(wrapped)
+	})
+	if err == nil && wrapped != nil {
+		wrapped.invalidate()
+	}
+	return val, err
+}
+
+type cachedRunner struct {
+	db     *DB
+	ttl    time.Duration
+	labels []string
+}
+
+func (r *cachedRunner) Run(statement string, params map[string]interface{}) cypher.Result {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return errResult{err: errors.WithMessage(err, "cypher/cache: failed to marshal params")}
+	}
+	key := statement + "\x00" + string(b)
+	if e := r.db.cache.get(key, r.db.now()); e != nil {
+		return &cachedResult{entry: e}
+	}
+	result := r.db.Run(statement, params)
+	rows, err := cypher.Collect(result)
+	if err != nil {
+		return errResult{err: err}
+	}
+	stats, err := result.Consume()
+	if err != nil {
+		return errResult{err: err}
+	}
+	e := &entry{
+		key:     key,
+		labels:  r.labels,
+		expires: r.db.now().Add(r.ttl),
+		columns: result.Columns(),
+		rows:    rows,
+		stats:   stats,
+		graph:   result.Graph(),
+	}
+	if stats == nil || !stats.ContainsUpdates() {
+		r.db.cache.put(e)
+	}
+	return &cachedResult{entry: e}
+}
+
+// Statements run with RunMany are not cached.
+func (r *cachedRunner) RunMany(cypherOrParams ...interface{}) cypher.Response {
+	return r.db.RunMany(cypherOrParams...)
+}
+
+// Collects the labels updated in a transaction, invalidating them when it is committed.
+type transaction struct {
+	cypher.Transaction
+	db      *DB
+	mu      sync.Mutex
+	updated map[string]bool
+	// Set when a statement without labels made updates, so that every label must be invalidated.
+	updatedAll bool
+}
+
+func (tx *transaction) Run(statement string, params map[string]interface{}) cypher.Result {
+	return &writeResult{
+		Result:   tx.Transaction.Run(statement, params),
+		labels:   labelsOf(statement),
+		onUpdate: tx.record,
+	}
+}
+
+// Run a statement with options, if the wrapped transaction accepts them.
+func (tx *transaction) RunWithOptions(opts cypher.RunOptions, statement string, params map[string]interface{}) cypher.Result {
+	return &writeResult{
+		Result:   cypher.RunWith(tx.Transaction, opts, statement, params),
+		labels:   labelsOf(statement),
+		onUpdate: tx.record,
+	}
+}
+
+func (tx *transaction) RunMany(cypherOrParams ...interface{}) cypher.Response {
+	return &writeResponse{
+		Response:   tx.Transaction.RunMany(cypherOrParams...),
+		statements: statementsOf(cypherOrParams),
+		onUpdate:   tx.record,
+	}
+}
+
+func (tx *transaction) Commit() error {
+	if err := tx.Transaction.Commit(); err != nil {
+		return err
+	}
+	tx.invalidate()
+	return nil
+}
+
+func (tx *transaction) record(labels map[string]bool) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if len(labels) == 0 {
+		tx.updatedAll = true
+	}
+	for label := range labels {
+		tx.updated[label] = true
+	}
+}
+
+func (tx *transaction) invalidate() {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.updatedAll {
+		tx.db.cache.purge()
+	} else if len(tx.updated) > 0 {
+		tx.db.cache.invalidate(tx.updated)
+	}
+}
+
+var (
+	labelPattern = regexp.MustCompile("[:|&]\\s*(`(?:[^`]|``)+`|[\\p{L}_][\\p{L}\\p{N}_]*)")
+	// Patterns which may reach nodes or relationships whose labels are not named.
+	unlabeledPatterns = []*regexp.Regexp{
+		// A node without a label, such as (), (n) or (n {id: 1}), not preceded by a function name.
+		regexp.MustCompile("(?:^|[^\\p{L}\\p{N}_`])\\(\\s*(?:[\\p{L}_][\\p{L}\\p{N}_]*|`(?:[^`]|``)+`)?\\s*[){$]"),
+		// A relationship without a type, such as -[]-, -[r]-> or -[*2]-.
+		regexp.MustCompile("-\\s*\\[\\s*(?:[\\p{L}_][\\p{L}\\p{N}_]*|`(?:[^`]|``)+`)?\\s*[\\]*{$]"),
+		// A relationship without brackets, such as (a)--(b) or (a)<--(b).
+		regexp.MustCompile("\\)\\s*<?-{1,2}>?\\s*\\("),
+		// Procedures and subqueries may update anything, and detaching deletes relationships to other labels.
+		regexp.MustCompile("(?i)\\b(?:CALL|DETACH)\\b"),
+	}
+)
+
+// Find the labels referenced by a statement, along with anything else which looks like one.
+// Finding too many only invalidates more than necessary.
+// No labels are returned, so that updates invalidate everything, if the statement matches an unlabeledPattern.
+func labelsOf(statement string) map[string]bool {
+	labels := make(map[string]bool)
+	statement = blankLiterals(statement)
+	for _, pattern := range unlabeledPatterns {
+		if pattern.MatchString(statement) {
+			return labels
+		}
+	}
+	for _, match := range labelPattern.FindAllStringSubmatch(statement, -1) {
+		label := match[1]
+		if strings.HasPrefix(label, "`") {
+			label = strings.ReplaceAll(label[1:len(label)-1], "``", "`")
+		}
+		labels[label] = true
+	}
+	return labels
+}
+
+// Blank out the string literals and comments of a statement, so that they are not mistaken for its patterns.
+func blankLiterals(statement string) string {
+	b := []byte(statement)
+	for i := 0; i < len(b); i++ {
+		switch {
+		case b[i] == '\'' || b[i] == '"':
+			quote := b[i]
+			for i++; i < len(b) && b[i] != quote; i++ {
+				if b[i] == '\\' && i+1 < len(b) {
+					b[i] = ' '
+					i++
+				}
+				b[i] = ' '
+			}
+		case b[i] == '`':
+			for i++; i < len(b) && b[i] != '`'; i++ {
+			}
+		case b[i] == '/' && i+1 < len(b) && b[i+1] == '/':
+			for ; i < len(b) && b[i] != '\n'; i++ {
+				b[i] = ' '
+			}
+		case b[i] == '/' && i+1 < len(b) && b[i+1] == '*':
+			for ; i < len(b) && !(b[i] == '*' && i+1 < len(b) && b[i+1] == '/'); i++ {
+				b[i] = ' '
+			}
+			if i+1 < len(b) {
+				b[i], b[i+1] = ' ', ' '
+				i++
+			}
+		}
+	}
+	return string(b)
+}
+
+func statementsOf(cypherOrParams []interface{}) []string {
+	statements := make([]string, 0, len(cypherOrParams))
+	for _, val := range cypherOrParams {
+		if s, ok := val.(string); ok {
+			statements = append(statements, s)
+		}
+	}
+	return statements
+}
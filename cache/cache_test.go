@@ -0,0 +1,229 @@
+package cache
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"github.com/tjbrockmeyer/cypher/internal/cyphertest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestLabelsOf(t *testing.T) {
+	// Statements which may update anything have no labels, so that their updates invalidate everything.
+	tests := []struct {
+		statement string
+		want      []string
+	}{
+		{"CREATE (n:Person {name: $name})", []string{"Person"}},
+		{"MERGE (n:Person {id: 1}) ON CREATE SET n.created = timestamp()", []string{"Person"}},
+		{"MATCH (n:Person)-[r:KNOWS]->(m:Person) DELETE r", []string{"KNOWS", "Person"}},
+		{"MATCH (n:A|B) SET n:C&D", []string{"A", "B", "C", "D"}},
+		{"MATCH (n: `Odd``Label`) SET n.x = 1", []string{"Odd`Label"}},
+		// Anything which looks like a label is included, which only invalidates more than necessary.
+		{"MATCH (n:Person) WITH count(n) AS c CREATE (:Stats {count: c})", []string{"Person", "Stats", "c"}},
+
+		{"MATCH (n) SET n.x = 1", nil},
+		{"MATCH (n {id: 1}) DELETE n", nil},
+		{"CREATE ()", nil},
+		{"MATCH (a:A), (b:B) CREATE (a)-[:R]->(b)", nil},
+		{"MATCH ()-[r]->() DELETE r", nil},
+		{"MATCH (a:A)-[*2]-(b:B) SET a.x = 1", nil},
+		{"MATCH (a:A)-[r {id: 1}]->(b:B) DELETE r", nil},
+		{"MATCH (a:A)--(b:B) SET a.x = 1", nil},
+		{"MATCH (a:A)<--(b:B) SET a.x = 1", nil},
+		{"MATCH (a:A)-->(b:B) SET a.x = 1", nil},
+		{"MATCH (n:Person) DETACH DELETE n", nil},
+		{"MATCH (n:Person) detach delete n", nil},
+		{"CALL apoc.create.node(['Person'], {})", nil},
+		{"MATCH (n:A) CALL { WITH n SET n.x = 1 }", nil},
+		{"UNWIND $rows AS row CALL { WITH row CREATE (:A) } IN TRANSACTIONS", nil},
+
+		// Labels and patterns in strings and comments are not those of the statement.
+		{"MATCH (n:Person) SET n.note = 'see (m) and :Secret'", []string{"Person"}},
+		{"MATCH (n:Person) SET n.note = \"call (m) :Secret \\\" (x)\"", []string{"Person"}},
+		{"MATCH (n:Person) SET n.note = 'it\\'s (m)'", []string{"Person"}},
+		{"MATCH (n:Person) // (m) :Secret CALL\nSET n.x = 1", []string{"Person"}},
+		{"MATCH (n:Person) /* (m) :Secret\nDETACH */ SET n.x = 1", []string{"Person"}},
+		// Names are kept to read the labels they quote, so those which look like patterns invalidate everything.
+		{"MATCH (n:`Call (m)`) SET n.x = 1", nil},
+		{"MATCH (n:`it's (m)`) SET n.x = 1", nil},
+		{"MATCH (n:`it's`) SET n.x = '(m)'", []string{"it's"}},
+		{"MATCH (n:Person) SET n.`:Secret` = 1", []string{"Person", "Secret"}},
+	}
+	for _, test := range tests {
+		got := labelsOf(test.statement)
+		var labels []string
+		for label := range got {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		if !reflect.DeepEqual(labels, test.want) {
+			t.Errorf("labelsOf(%q) = %q, expected %q", test.statement, labels, test.want)
+		}
+	}
+}
+
+// Answer statements which set or create with updates, and any other statement with a row.
+func respond(statement string, params map[string]interface{}) *cyphertest.Result {
+	if strings.Contains(statement, "SET") || strings.Contains(statement, "CREATE") {
+		result := cyphertest.NewResult(nil)
+		result.Stats = cyphertest.Stats{"properties_set": 1}
+		return result
+	}
+	return cyphertest.NewResult([]string{"n"}, []interface{}{1.0})
+}
+
+// The labels, of Movie and Person, whose cached queries are still cached.
+func cachedLabels(t *testing.T, db *DB, fake *cyphertest.DB) []string {
+	var labels []string
+	for _, label := range []string{"Movie", "Person"} {
+		runs := len(fake.Runs())
+		if _, err := cypher.Collect(db.Cached(0, label).Run("MATCH (n:"+label+") RETURN n", nil)); err != nil {
+			t.Fatal(err)
+		}
+		if len(fake.Runs()) == runs {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+func TestInvalidation(t *testing.T) {
+	errJob := errors.New("job failed")
+	tests := []struct {
+		name  string
+		write func(db *DB) error
+		want  []string
+	}{
+		{"read", func(db *DB) error {
+			_, err := db.Run("MATCH (n:Person) RETURN n", nil).Consume()
+			return err
+		}, []string{"Movie", "Person"}},
+		{"labeled write", func(db *DB) error {
+			_, err := db.Run("MATCH (n:Person) SET n.x = 1", nil).Consume()
+			return err
+		}, []string{"Movie"}},
+		{"labeled write read by rows", func(db *DB) error {
+			_, err := cypher.Collect(db.Run("MATCH (n:Person) SET n.x = 1", nil))
+			return err
+		}, []string{"Movie"}},
+		{"unlabeled write", func(db *DB) error {
+			_, err := db.Run("MATCH (n) SET n.x = 1", nil).Consume()
+			return err
+		}, nil},
+		{"RunMany", func(db *DB) error {
+			return db.RunMany("MATCH (n:Movie) RETURN n", "MATCH (n:Person) SET n.x = 1").Consume()
+		}, []string{"Movie"}},
+		{"RunWith", func(db *DB) error {
+			_, err := cypher.RunWith(db, cypher.RunOptions{Graph: true}, "MATCH (n:Movie) SET n.x = 1", nil).Consume()
+			return err
+		}, []string{"Person"}},
+		{"uncommitted transaction", func(db *DB) error {
+			tx, err := db.TX()
+			if err != nil {
+				return err
+			}
+			_, err = tx.Run("MATCH (n:Person) SET n.x = 1", nil).Consume()
+			return err
+		}, []string{"Movie", "Person"}},
+		{"committed transaction", func(db *DB) error {
+			tx, err := db.TX()
+			if err != nil {
+				return err
+			}
+			if _, err = tx.Run("MATCH (n:Person) SET n.x = 1", nil).Consume(); err != nil {
+				return err
+			}
+			return tx.Commit()
+		}, []string{"Movie"}},
+		{"committed transaction run with options", func(db *DB) error {
+			tx, err := db.TX()
+			if err != nil {
+				return err
+			}
+			if _, err = cypher.RunWith(tx, cypher.RunOptions{Graph: true}, "MATCH (n:Person) SET n.x = 1", nil).Consume(); err != nil {
+				return err
+			}
+			return tx.Commit()
+		}, []string{"Movie"}},
+		{"committed transaction with an unlabeled write", func(db *DB) error {
+			tx, err := db.TX()
+			if err != nil {
+				return err
+			}
+			if err = tx.RunMany("MATCH (n:Person) SET n.x = 1", "CREATE ()").Consume(); err != nil {
+				return err
+			}
+			return tx.Commit()
+		}, nil},
+		{"rolled back transaction", func(db *DB) error {
+			tx, err := db.TX()
+			if err != nil {
+				return err
+			}
+			if _, err = tx.Run("MATCH (n) SET n.x = 1", nil).Consume(); err != nil {
+				return err
+			}
+			return tx.Rollback()
+		}, []string{"Movie", "Person"}},
+		{"TXJob", func(db *DB) error {
+			_, err := db.TXJob(func(tx cypher.Transaction) (interface{}, error) {
+				_, err := tx.Run("MATCH (n:Movie) SET n.x = 1", nil).Consume()
+				return nil, err
+			})
+			return err
+		}, []string{"Person"}},
+		{"failed TXJob", func(db *DB) error {
+			_, err := db.TXJob(func(tx cypher.Transaction) (interface{}, error) {
+				if _, err := tx.Run("MATCH (n:Movie) SET n.x = 1", nil).Consume(); err != nil {
+					return nil, err
+				}
+				return nil, errJob
+			})
+			if err != errJob {
+				return errors.Errorf("TXJob returned %v, expected the error of the job", err)
+			}
+			return nil
+		}, []string{"Movie", "Person"}},
+	}
+	for _, test := range tests {
+		fake := &cyphertest.DB{Respond: respond}
+		db := New(fake, Options{})
+		if labels := cachedLabels(t, db, fake); labels != nil {
+			t.Fatalf("%s: %v were cached before they were run", test.name, labels)
+		}
+		if err := test.write(db); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if labels := cachedLabels(t, db, fake); !reflect.DeepEqual(labels, test.want) {
+			t.Errorf("%s: %v are still cached, expected %v", test.name, labels, test.want)
+		}
+	}
+}
+
+func TestRunWithOptions(t *testing.T) {
+	fake := &cyphertest.DB{}
+	db := New(fake, Options{})
+	opts := cypher.RunOptions{Graph: true}
+	if _, err := cypher.RunWith(db, opts, "MATCH (n:Movie) RETURN n", nil).Consume(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := db.TXJob(func(tx cypher.Transaction) (interface{}, error) {
+		return cypher.RunWith(tx, opts, "MATCH (n:Movie) RETURN n", nil).Consume()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs := fake.Runs()
+	if len(runs) != 2 {
+		t.Fatalf("%v statements were run, expected 2", len(runs))
+	}
+	for _, run := range runs {
+		if run.Options != opts {
+			t.Errorf("statement in transaction %v was run with %+v, expected %+v", run.TX, run.Options, opts)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"container/list"
+	"github.com/tjbrockmeyer/cypher"
+	"sync"
+	"time"
+)
+
+// A fully collected result.
+type entry struct {
+	key     string
+	labels  []string
+	expires time.Time
+	columns []string
+	rows    []cypher.Row
+	stats   cypher.Stats
+	graph   *cypher.Graph
+}
+
+// A least-recently-used set of entries, safe for concurrent use.
+type lru struct {
+	mu      sync.Mutex
+	max     int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newLRU(max int) *lru {
+	return &lru{max: max, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *lru) get(key string, now time.Time) *entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*entry)
+	if now.After(e.expires) {
+		c.remove(el)
+		return nil
+	}
+	c.order.MoveToFront(el)
+	return e
+}
+
+func (c *lru) put(e *entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[e.key]; ok {
+		c.remove(el)
+	}
+	c.entries[e.key] = c.order.PushFront(e)
+	for c.max > 0 && c.order.Len() > c.max {
+		c.remove(c.order.Back())
+	}
+}
+
+// Remove every entry which depends on any of the labels.
+// Entries which declared no labels depend on every label, and no labels at all invalidates every entry.
+func (c *lru) invalidate(labels map[string]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, el := range c.entries {
+		if len(labels) == 0 {
+			c.remove(el)
+			continue
+		}
+		e := el.Value.(*entry)
+		if len(e.labels) == 0 {
+			c.remove(el)
+			continue
+		}
+		for _, label := range e.labels {
+			if labels[label] {
+				c.remove(el)
+				break
+			}
+		}
+	}
+}
+
+func (c *lru) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+func (c *lru) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *lru) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*entry).key)
+}
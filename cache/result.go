@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"github.com/tjbrockmeyer/cypher"
+)
+
+// Replays a cached entry as a result.
+type cachedResult struct {
+	entry *entry
+	index int
+}
+
+func (r *cachedResult) Index() int {
+	return 0
+}
+
+func (r *cachedResult) Columns() []string {
+	return r.entry.columns
+}
+
+func (r *cachedResult) NextRow() bool {
+	if r.index >= len(r.entry.rows) {
+		return false
+	}
+	r.index++
+	return true
+}
+
+func (r *cachedResult) GetRow() cypher.Row {
+	if r.index == 0 {
+		return nil
+	}
+	return r.entry.rows[r.index-1]
+}
+
+func (r *cachedResult) Err() error {
+	return nil
+}
+
+func (r *cachedResult) Consume() (cypher.Stats, error) {
+	r.index = len(r.entry.rows)
+	return r.entry.stats, nil
+}
+
+func (r *cachedResult) Graph() *cypher.Graph {
+	return r.entry.graph
+}
+
+// A result which could not be run, deferring its error.
+type errResult struct {
+	err error
+}
+
+func (r errResult) Index() int                     { return 0 }
+func (r errResult) Columns() []string              { return nil }
+func (r errResult) NextRow() bool                  { return false }
+func (r errResult) GetRow() cypher.Row             { return nil }
+func (r errResult) Err() error                     { return r.err }
+func (r errResult) Consume() (cypher.Stats, error) { return nil, r.err }
+func (r errResult) Graph() *cypher.Graph           { return new(cypher.Graph) }
+
+// Reports the labels of a statement once its stats show that it made updates.
+type writeResult struct {
+	cypher.Result
+	labels   map[string]bool
+	onUpdate func(labels map[string]bool)
+	done     bool
+}
+
+func (r *writeResult) NextRow() bool {
+	if r.Result.NextRow() {
+		return true
+	}
+	if r.Result.Err() == nil {
+		stats, err := r.Result.Consume()
+		if err == nil {
+			r.report(stats)
+		}
+	}
+	return false
+}
+
+func (r *writeResult) Consume() (cypher.Stats, error) {
+	stats, err := r.Result.Consume()
+	if err == nil {
+		r.report(stats)
+	}
+	return stats, err
+}
+
+func (r *writeResult) report(stats cypher.Stats) {
+	if r.done || stats == nil || !stats.ContainsUpdates() {
+		return
+	}
+	r.done = true
+	r.onUpdate(r.labels)
+}
+
+type writeResponse struct {
+	cypher.Response
+	statements []string
+	onUpdate   func(labels map[string]bool)
+}
+
+func (r *writeResponse) GetResult() cypher.Result {
+	result := r.Response.GetResult()
+	if result == nil || result.Index() >= len(r.statements) {
+		return result
+	}
+	return &writeResult{Result: result, labels: labelsOf(r.statements[result.Index()]), onUpdate: r.onUpdate}
+}
+
+func (r *writeResponse) Consume() error {
+	for r.NextResult() {
+		if _, err := r.GetResult().Consume(); err != nil {
+			return err
+		}
+	}
+	return r.Err()
+}
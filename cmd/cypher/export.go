@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher/export"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+func runExport(args []string) error {
+	var conn connFlags
+	params := make(paramFlags)
+	fs := flag.NewFlagSet("cypher export", flag.ExitOnError)
+	conn.register(fs)
+	fs.Var(params, "param", "a parameter of the query as name=json, may be repeated")
+	query := fs.String("query", "", "the query whose results are exported")
+	queryFile := fs.String("query-file", "", "a file containing the query whose results are exported")
+	format := fs.String("format", "csv", "the output format: csv, jsonl or columnar")
+	flatten := fs.Bool("flatten", false, "expand maps and nodes into a column per property")
+	listSeparator := fs.String("list-separator", "", "join lists of scalars in csv output with this separator instead of encoding them as json")
+	groupSize := fs.Int("group-size", 1000, "the number of rows per group in columnar output")
+	output := fs.String("o", "", "the file to write to, defaults to stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	statement := *query
+	if *queryFile != "" {
+		b, err := ioutil.ReadFile(*queryFile)
+		if err != nil {
+			return err
+		}
+		statement = string(b)
+	}
+	if statement == "" {
+		return errors.New("export: one of -query or -query-file is required")
+	}
+	opts := export.Options{ListSeparator: *listSeparator, RowGroupSize: *groupSize}
+	var err error
+	if opts.Format, err = export.ParseFormat(*format); err != nil {
+		return err
+	}
+	if *flatten {
+		opts.Flatten = export.FlattenDotted
+	}
+
+	db, err := conn.connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	out := io.Writer(os.Stdout)
+	var f *os.File
+	if *output != "" {
+		if f, err = os.Create(*output); err != nil {
+			return err
+		}
+		out = f
+	}
+	count, err := export.Write(out, db.Run(statement, params), opts)
+	if f != nil {
+		// The file is only complete once it is closed.
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "exported %d rows to %s\n", count, *output)
+	}
+	return nil
+}
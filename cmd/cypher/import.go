@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"github.com/tjbrockmeyer/cypher/csvimport"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func runImport(args []string) error {
+	var conn connFlags
+	fs := flag.NewFlagSet("cypher import", flag.ExitOnError)
+	conn.register(fs)
+	mappingFile := fs.String("mapping", "", "the json file mapping csv columns to properties")
+	rejectsFile := fs.String("rejects", "",
+		"write rows which fail to import to this csv file, or with many csv files, to one file per csv file named after it")
+	batchSize := fs.Int("batch", 1000, "the number of rows to import per statement")
+	quiet := fs.Bool("q", false, "do not report progress")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *mappingFile == "" || fs.NArg() == 0 {
+		return errors.New("import: usage: cypher import -mapping <file> [flags] <csv file>...")
+	}
+	mapping, err := csvimport.LoadMapping(*mappingFile)
+	if err != nil {
+		return err
+	}
+
+	opts := csvimport.Options{BatchSize: *batchSize}
+	db, err := conn.connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var rejected int
+	for _, path := range fs.Args() {
+		if !*quiet {
+			opts.Progress = func(p csvimport.Progress) {
+				fmt.Fprintf(os.Stderr, "\r%s: %d read, %d imported, %d rejected", path, p.Read, p.Imported, p.Rejected)
+			}
+		}
+		p, err := importFile(db, path, mapping, opts, rejectsPath(*rejectsFile, path, fs.NArg()))
+		if !*quiet {
+			fmt.Fprintln(os.Stderr)
+		}
+		if err != nil {
+			return errors.WithMessage(err, path)
+		}
+		rejected += p.Rejected
+	}
+	if rejected > 0 {
+		return errors.Errorf("import: %d rows were rejected", rejected)
+	}
+	return nil
+}
+
+func importFile(runner cypher.Runner, path string, mapping *csvimport.Mapping, opts csvimport.Options,
+	rejects string) (csvimport.Progress, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return csvimport.Progress{}, err
+	}
+	defer f.Close()
+	if rejects != "" {
+		r, err := os.Create(rejects)
+		if err != nil {
+			return csvimport.Progress{}, err
+		}
+		defer r.Close()
+		opts.Rejects = r
+	}
+	return csvimport.Import(runner, f, mapping, opts)
+}
+
+// Get the rejects file for a csv file. Each csv file has its own, as their headers may differ,
+// so with many csv files the name of each is inserted before the extension: rejects.people.csv
+func rejectsPath(rejects, path string, files int) string {
+	if rejects == "" || files == 1 {
+		return rejects
+	}
+	ext := filepath.Ext(rejects)
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return strings.TrimSuffix(rejects, ext) + "." + name + ext
+}
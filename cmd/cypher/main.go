@@ -0,0 +1,101 @@
+// Command cypher is a command line client for neo4j built on package cypher.
+//
+// Usage:
+//
+//	cypher [shell] [connection flags] [-f file]
+//	cypher export [connection flags] -query <cypher> [-format csv|jsonl|columnar] [-o file]
+//	cypher import [connection flags] -mapping <file> [-rejects file] <csv file>...
+//
+// With no file, the shell is interactive when stdin is a terminal.
+// Otherwise statements are read from the file or stdin and run in order, stopping at the first error.
+//
+// The export subcommand streams the results of a query to a file.
+// The import subcommand loads local csv files as described by a csvimport.Mapping file.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	_ "github.com/tjbrockmeyer/cypher/neohttp"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Flags used by every subcommand to connect to the database.
+type connFlags struct {
+	driver   string
+	uri      string
+	dbName   string
+	username string
+	password string
+	debug    bool
+}
+
+func (c *connFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.driver, "driver", "neohttp", "name of the cypher driver to connect with")
+	fs.StringVar(&c.uri, "uri", envOr("NEO4J_URI", "http://localhost:7474"), "uri of the database ($NEO4J_URI)")
+	fs.StringVar(&c.dbName, "db", envOr("NEO4J_DATABASE", "neo4j"), "name of the database ($NEO4J_DATABASE)")
+	fs.StringVar(&c.username, "u", envOr("NEO4J_USERNAME", ""), "username ($NEO4J_USERNAME)")
+	fs.StringVar(&c.password, "p", envOr("NEO4J_PASSWORD", ""), "password ($NEO4J_PASSWORD)")
+	fs.BoolVar(&c.debug, "debug", false, "enable debug logging of requests and responses")
+}
+
+func (c *connFlags) connect() (cypher.DB, error) {
+	cypher.Debug = c.debug
+	return cypher.Connect(c.driver, c.uri, c.dbName, c.username, c.password)
+}
+
+func envOr(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return fallback
+}
+
+// Parameters given as repeated name=json flags.
+type paramFlags map[string]interface{}
+
+func (p paramFlags) String() string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func (p paramFlags) Set(s string) error {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return errors.New("parameters must take the form name=json")
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(parts[1]), &value); err != nil {
+		return errors.WithMessage(err, "failed to parse the value of parameter "+parts[0]+" as json")
+	}
+	p[parts[0]] = value
+	return nil
+}
+
+func main() {
+	args := os.Args[1:]
+	command := runShell
+	if len(args) > 0 {
+		switch args[0] {
+		case "shell":
+			args = args[1:]
+		case "export":
+			command, args = runExport, args[1:]
+		case "import":
+			command, args = runImport, args[1:]
+		}
+	}
+	if err := command(args); err != nil {
+		fmt.Fprintln(os.Stderr, "cypher:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const shellHelp = `Statements may span multiple lines and are run when terminated by ';'.
+Tab completes commands, labels, relationship types, property keys after '.' and parameters after '$'.
+The up and down arrows recall statements, including those of earlier sessions from ~/.cypher_history.
+Commands:
+  :param <name> => <json>  declare a parameter available to all following statements
+  :params                  list the declared parameters
+  :params clear            remove all declared parameters
+  :begin                   open a transaction
+  :commit                  commit the open transaction
+  :rollback                roll back the open transaction
+  :history                 list the statements run in this session
+  :help                    show this message
+  :exit                    exit the shell`
+
+func runShell(args []string) error {
+	var conn connFlags
+	fs := flag.NewFlagSet("cypher", flag.ExitOnError)
+	conn.register(fs)
+	file := fs.String("f", "", "run the statements in the given file instead of reading from stdin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	in := io.Reader(os.Stdin)
+	interactive := *file == "" && term.IsTerminal(int(os.Stdin.Fd()))
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	db, err := conn.connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	s := &shell{
+		db:          db,
+		out:         os.Stdout,
+		params:      make(map[string]interface{}),
+		interactive: interactive,
+	}
+	if !interactive {
+		return s.run(newScanLines(in))
+	}
+	s.historyFile = historyPath()
+	restore, err := s.openTerminal()
+	if err != nil {
+		return err
+	}
+	defer restore()
+	fmt.Fprintf(s.out, "Connected to %s as database %s. Type :help for help.\n", conn.uri, conn.dbName)
+	return s.run(s.term)
+}
+
+type shell struct {
+	db          cypher.DB
+	tx          cypher.Transaction
+	out         io.Writer
+	params      map[string]interface{}
+	interactive bool
+	history     []string
+	historyFile string
+	exit        bool
+
+	// Set in interactive mode.
+	term   *term.Terminal
+	recall *history
+	schema schemaNames
+}
+
+// Read and run statements and commands until the input ends or the shell is exited.
+// When not interactive, the first error stops the shell and is returned.
+func (s *shell) run(in lineReader) error {
+	var buf strings.Builder
+	s.prompt(in, false)
+	for !s.exit {
+		line, err := in.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if buf.Len() == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			if s.recall != nil {
+				s.recall.add(strings.TrimSpace(line))
+			}
+			if err := s.handle(s.command(strings.TrimSpace(line))); err != nil {
+				return err
+			}
+			s.prompt(in, false)
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+		statements, rest := splitStatements(buf.String())
+		buf.Reset()
+		buf.WriteString(rest)
+		for _, statement := range statements {
+			if err := s.handle(s.statement(statement)); err != nil {
+				return err
+			}
+		}
+		s.prompt(in, strings.TrimSpace(rest) != "")
+	}
+	if !s.exit && strings.TrimSpace(buf.String()) != "" {
+		if err := s.handle(s.statement(strings.TrimSpace(buf.String()))); err != nil {
+			return err
+		}
+	}
+	if s.tx != nil {
+		if s.interactive {
+			fmt.Fprintln(s.out, "rolling back the open transaction")
+		}
+		return errors.WithMessage(s.tx.Rollback(), "failed to roll back the open transaction")
+	}
+	return nil
+}
+
+// Report an error in interactive mode, or return it otherwise.
+func (s *shell) handle(err error) error {
+	if err == nil {
+		return nil
+	}
+	if s.interactive {
+		fmt.Fprintf(s.out, "error: %v\n", err)
+		return nil
+	}
+	return err
+}
+
+func (s *shell) prompt(in lineReader, continuation bool) {
+	switch {
+	case continuation:
+		in.SetPrompt("     | ")
+	case s.tx != nil:
+		in.SetPrompt("neo4j# ")
+	default:
+		in.SetPrompt("neo4j> ")
+	}
+}
+
+func (s *shell) runner() cypher.Runner {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func (s *shell) statement(statement string) error {
+	s.remember(statement)
+	result := s.runner().Run(statement, s.params)
+	if err := result.Err(); err != nil {
+		return err
+	}
+	rows, err := cypher.Collect(result)
+	if err != nil {
+		return err
+	}
+	stats, err := result.Consume()
+	if err != nil {
+		return err
+	}
+	if stats != nil && stats.ContainsUpdates() {
+		// New labels and property keys may have been created.
+		s.schema.loaded = false
+	}
+	if len(result.Columns()) > 0 {
+		writeTable(s.out, result.Columns(), rows)
+	}
+	fmt.Fprintln(s.out, summarize(len(rows), stats))
+	return nil
+}
+
+func (s *shell) command(line string) error {
+	name, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		name, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+	switch name {
+	case ":param":
+		return s.declareParam(arg)
+	case ":params":
+		if arg == "clear" {
+			s.params = make(map[string]interface{})
+			return nil
+		}
+		s.listParams()
+	case ":begin":
+		if s.tx != nil {
+			return errors.New("a transaction is already open")
+		}
+		tx, err := s.db.TX()
+		if err != nil {
+			return err
+		}
+		s.tx = tx
+	case ":commit":
+		if s.tx == nil {
+			return errors.New("there is no open transaction to commit")
+		}
+		defer func() { s.tx = nil }()
+		return s.tx.Commit()
+	case ":rollback":
+		if s.tx == nil {
+			return errors.New("there is no open transaction to roll back")
+		}
+		defer func() { s.tx = nil }()
+		return s.tx.Rollback()
+	case ":history":
+		for i, statement := range s.history {
+			fmt.Fprintf(s.out, "%4d  %s\n", i+1, statement)
+		}
+	case ":help":
+		fmt.Fprintln(s.out, shellHelp)
+	case ":exit", ":quit":
+		s.exit = true
+	default:
+		return errors.New("unknown command: " + name + " - type :help for a list of commands")
+	}
+	return nil
+}
+
+// Declare a parameter using the form: name => value
+// The value is parsed as json.
+func (s *shell) declareParam(arg string) error {
+	parts := strings.SplitN(arg, "=>", 2)
+	if len(parts) != 2 {
+		return errors.New("usage: :param <name> => <json>")
+	}
+	name := strings.TrimPrefix(strings.TrimSpace(parts[0]), "$")
+	if name == "" {
+		return errors.New("parameter name cannot be empty")
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(parts[1])), &value); err != nil {
+		return errors.WithMessage(err, "failed to parse the value of parameter "+name+" as json")
+	}
+	s.params[name] = value
+	return nil
+}
+
+func (s *shell) listParams() {
+	names := make([]string, 0, len(s.params))
+	for name := range s.params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(s.out, ":param %s => %s\n", name, formatValue(s.params[name]))
+	}
+}
+
+// Record a statement in the session history, appending it to the history file in interactive mode.
+func (s *shell) remember(statement string) {
+	s.history = append(s.history, statement)
+	if s.recall != nil {
+		s.recall.add(strings.ReplaceAll(statement, "\n", " ") + ";")
+	}
+	if s.historyFile == "" {
+		return
+	}
+	f, err := os.OpenFile(s.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, strings.ReplaceAll(statement, "\n", " ")+";")
+}
+
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".cypher_history")
+}
+
+// Split text into the complete statements terminated by ';', returning any unterminated remainder.
+// Semicolons inside of quotes, backticks and comments do not terminate a statement.
+func splitStatements(text string) (statements []string, rest string) {
+	start := 0
+	var quote rune
+	escaped, lineComment, blockComment := false, false, false
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case lineComment:
+			lineComment = c != '\n'
+		case blockComment:
+			if c == '*' && i+1 < len(runes) && runes[i+1] == '/' {
+				blockComment = false
+				i++
+			}
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				escaped = true
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			lineComment = true
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			blockComment = true
+			i++
+		case c == ';':
+			if statement := strings.TrimSpace(string(runes[start:i])); statement != "" {
+				statements = append(statements, statement)
+			}
+			start = i + 1
+		}
+	}
+	return statements, string(runes[start:])
+}
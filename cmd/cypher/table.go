@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/tjbrockmeyer/cypher"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Write the rows as a table with aligned columns.
+func writeTable(w io.Writer, columns []string, rows []cypher.Row) {
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = utf8.RuneCountInString(column)
+	}
+	cells := make([][]string, len(rows))
+	for r, row := range rows {
+		cells[r] = make([]string, len(columns))
+		for i := range columns {
+			cells[r][i] = formatValue(row.GetAt(i))
+			if n := utf8.RuneCountInString(cells[r][i]); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var separator strings.Builder
+	separator.WriteByte('+')
+	for _, width := range widths {
+		separator.WriteString(strings.Repeat("-", width+2))
+		separator.WriteByte('+')
+	}
+	writeLine := func(values []string) {
+		var line strings.Builder
+		line.WriteByte('|')
+		for i, value := range values {
+			line.WriteString(" " + value + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(value)) + " |")
+		}
+		fmt.Fprintln(w, line.String())
+	}
+
+	fmt.Fprintln(w, separator.String())
+	writeLine(columns)
+	fmt.Fprintln(w, separator.String())
+	for _, values := range cells {
+		writeLine(values)
+	}
+	fmt.Fprintln(w, separator.String())
+}
+
+// Format a value the way it would be written in cypher.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
+
+// Summarize the number of rows and any changes made by a statement, whose stats may be nil when not reported.
+func summarize(rowCount int, stats cypher.Stats) string {
+	parts := []string{fmt.Sprintf("%d row%s", rowCount, plural(rowCount))}
+	if stats == nil {
+		return parts[0]
+	}
+	counters := []struct {
+		name  string
+		count int
+	}{
+		{"nodes created", stats.NodesCreated()},
+		{"nodes deleted", stats.NodesDeleted()},
+		{"relationships created", stats.RelationshipsCreated()},
+		{"relationships deleted", stats.RelationshipDeleted()},
+		{"properties set", stats.PropertiesSet()},
+		{"labels added", stats.LabelsAdded()},
+		{"labels removed", stats.LabelsRemoved()},
+		{"indexes added", stats.IndexesAdded()},
+		{"indexes removed", stats.IndexesRemoved()},
+		{"constraints added", stats.ConstraintsAdded()},
+		{"constraints removed", stats.ConstraintsRemoved()},
+		{"system updates", stats.SystemUpdates()},
+	}
+	for _, c := range counters {
+		if c.count != 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d", c.name, c.count))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/tjbrockmeyer/cypher"
+	"github.com/tjbrockmeyer/cypher/internal/cyphertest"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		rows  int
+		stats cypher.Stats
+		want  string
+	}{
+		{0, nil, "0 rows"},
+		{1, nil, "1 row"},
+		{2, cyphertest.Stats{}, "2 rows"},
+		{0, cyphertest.Stats{"nodes_created": 1, "properties_set": 2}, "0 rows, nodes created: 1, properties set: 2"},
+		{0, cyphertest.Stats{"system_updates": 1}, "0 rows, system updates: 1"},
+	}
+	for _, test := range tests {
+		if got := summarize(test.rows, test.stats); got != test.want {
+			t.Errorf("summarize(%d, %v) = %q, expected %q", test.rows, test.stats, got, test.want)
+		}
+	}
+}
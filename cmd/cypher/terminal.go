@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"github.com/tjbrockmeyer/cypher"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// The number of statements loaded from the history file for recall.
+const historyLimit = 1000
+
+// The commands offered by completion at the start of a line.
+var shellCommands = []string{"param", "params", "begin", "commit", "rollback", "history", "help", "exit"}
+
+// A source of input lines: an interactive terminal, or lines scanned from a file or pipe.
+type lineReader interface {
+	ReadLine() (string, error)
+	SetPrompt(prompt string)
+}
+
+type scanLines struct {
+	scanner *bufio.Scanner
+}
+
+func newScanLines(in io.Reader) *scanLines {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return &scanLines{scanner: scanner}
+}
+
+func (s *scanLines) ReadLine() (string, error) {
+	if s.scanner.Scan() {
+		return s.scanner.Text(), nil
+	}
+	if err := s.scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", io.EOF
+}
+
+func (s *scanLines) SetPrompt(string) {}
+
+// Put the terminal on stdin into raw mode and edit lines with it, with history recall and tab completion.
+// The returned function restores the terminal.
+func (s *shell) openTerminal() (func(), error) {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "")
+	if width, height, err := term.GetSize(fd); err == nil {
+		_ = t.SetSize(width, height)
+	}
+	s.recall = &history{}
+	s.recall.load(s.historyFile)
+	t.History = s.recall
+	t.AutoCompleteCallback = s.complete
+	s.term = t
+	s.out = t
+	return func() { _ = term.Restore(fd, state) }, nil
+}
+
+// The statements and commands which may be recalled with the arrow keys.
+// Lines are not recorded as they are read, so that statements spanning many lines are recalled whole.
+type history struct {
+	entries []string
+}
+
+func (h *history) Add(string) {}
+
+func (h *history) Len() int {
+	return len(h.entries)
+}
+
+func (h *history) At(i int) string {
+	return h.entries[len(h.entries)-1-i]
+}
+
+func (h *history) add(entry string) {
+	if len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry {
+		return
+	}
+	h.entries = append(h.entries, entry)
+}
+
+// Load the most recent statements of the history file, which holds one statement per line.
+func (h *history) load(file string) {
+	if file == "" {
+		return
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			h.add(line)
+		}
+	}
+	if len(h.entries) > historyLimit {
+		h.entries = h.entries[len(h.entries)-historyLimit:]
+	}
+}
+
+// The names offered by completion, loaded from the database when first needed.
+type schemaNames struct {
+	loaded       bool
+	labels       []string
+	propertyKeys []string
+}
+
+func (s *shell) schemaNames() *schemaNames {
+	if !s.schema.loaded {
+		s.schema.loaded = true
+		labels, _ := cypher.ColumnAs[string](s.db.Run("CALL db.labels() YIELD label RETURN label", nil), "label")
+		types, _ := cypher.ColumnAs[string](s.db.Run(
+			"CALL db.relationshipTypes() YIELD relationshipType RETURN relationshipType", nil), "relationshipType")
+		s.schema.labels = append(labels, types...)
+		s.schema.propertyKeys, _ = cypher.ColumnAs[string](s.db.Run(
+			"CALL db.propertyKeys() YIELD propertyKey RETURN propertyKey", nil), "propertyKey")
+	}
+	return &s.schema
+}
+
+// Complete the word before the cursor when tab is pressed:
+// commands at the start of a line, labels and relationship types after ':',
+// property keys after '.' and declared parameters after '$'.
+// When there are many candidates, the word is extended to their common prefix, or they are listed.
+func (s *shell) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+	start := pos
+	for start > 0 && isNameByte(line[start-1]) {
+		start--
+	}
+	if start == 0 {
+		return "", 0, false
+	}
+	word := line[start:pos]
+	var names []string
+	switch line[start-1] {
+	case ':':
+		if strings.TrimSpace(line[:start-1]) == "" {
+			names = shellCommands
+		} else {
+			names = s.schemaNames().labels
+		}
+	case '.':
+		names = s.schemaNames().propertyKeys
+	case '$':
+		for name := range s.params {
+			names = append(names, name)
+		}
+	default:
+		return "", 0, false
+	}
+	var candidates []string
+	for _, name := range names {
+		if strings.HasPrefix(name, word) {
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Strings(candidates)
+	switch len(candidates) {
+	case 0:
+		return line, pos, true
+	case 1:
+		completed := quoteName(candidates[0])
+		return line[:start] + completed + line[pos:], start + len(completed), true
+	}
+	prefix := commonPrefix(candidates)
+	if len(prefix) > len(word) && quoteName(prefix) == prefix {
+		return line[:start] + prefix + line[pos:], start + len(prefix), true
+	}
+	_, _ = s.term.Write([]byte(strings.Join(candidates, "  ") + "\n"))
+	return line, pos, true
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// Quote a name in backticks if it is not a plain identifier.
+func quoteName(name string) string {
+	for i := 0; i < len(name); i++ {
+		if !isNameByte(name[i]) || i == 0 && name[i] >= '0' && name[i] <= '9' {
+			return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+		}
+	}
+	return name
+}
+
+func commonPrefix(names []string) string {
+	prefix := names[0]
+	for _, name := range names[1:] {
+		for !strings.HasPrefix(name, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
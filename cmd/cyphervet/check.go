@@ -0,0 +1,515 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+const cypherPath = "github.com/tjbrockmeyer/cypher"
+
+// The functions of package cypher which run a statement, by the index of their statement argument.
+// The params argument follows the statement.
+var statementFuncs = map[string]int{
+	"Query":    1,
+	"RunWith":  2,
+	"RunAsync": 1,
+}
+
+type diagnostic struct {
+	pos     token.Position
+	message string
+}
+
+type checker struct {
+	fset *token.FileSet
+	info *types.Info
+
+	runner      *types.Interface
+	db          *types.Interface
+	result      types.Type
+	response    types.Type
+	diagnostics []diagnostic
+}
+
+// Returns nil if the package does not depend on package cypher.
+func newChecker(fset *token.FileSet, pkg *types.Package, info *types.Info) *checker {
+	cypherPkg := findPackage(pkg, cypherPath, make(map[*types.Package]bool))
+	if cypherPkg == nil {
+		return nil
+	}
+	lookup := func(name string) types.Type {
+		obj := cypherPkg.Scope().Lookup(name)
+		if obj == nil {
+			return nil
+		}
+		return obj.Type()
+	}
+	runner, _ := lookup("Runner").Underlying().(*types.Interface)
+	db, _ := lookup("DB").Underlying().(*types.Interface)
+	if runner == nil || db == nil {
+		return nil
+	}
+	return &checker{
+		fset:     fset,
+		info:     info,
+		runner:   runner,
+		db:       db,
+		result:   lookup("Result"),
+		response: lookup("Response"),
+	}
+}
+
+func findPackage(pkg *types.Package, path string, seen map[*types.Package]bool) *types.Package {
+	if pkg.Path() == path {
+		return pkg
+	}
+	seen[pkg] = true
+	for _, imp := range pkg.Imports() {
+		if seen[imp] {
+			continue
+		}
+		if found := findPackage(imp, path, seen); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func (c *checker) report(pos token.Pos, format string, args ...interface{}) {
+	c.diagnostics = append(c.diagnostics, diagnostic{pos: c.fset.Position(pos), message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) sortedDiagnostics() []diagnostic {
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		a, b := c.diagnostics[i].pos, c.diagnostics[j].pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return c.diagnostics
+}
+
+func (c *checker) checkFile(f *ast.File) {
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+			c.checkFunc(fn.Body)
+		}
+	}
+}
+
+// The body of a function, with the parent of each of its nodes.
+type funcBody struct {
+	body    *ast.BlockStmt
+	parents map[ast.Node]ast.Node
+}
+
+func (c *checker) checkFunc(body *ast.BlockStmt) {
+	fb := &funcBody{body: body, parents: make(map[ast.Node]ast.Node)}
+	var stack []ast.Node
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		if len(stack) > 0 {
+			fb.parents[n] = stack[len(stack)-1]
+		}
+		stack = append(stack, n)
+		return true
+	})
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			c.checkCall(fb, n)
+		case *ast.ExprStmt:
+			c.checkDiscarded(n)
+		case *ast.AssignStmt:
+			if len(n.Lhs) == len(n.Rhs) {
+				for i := range n.Lhs {
+					c.checkAssign(fb, n.Lhs[i], n.Rhs[i])
+				}
+			} else if len(n.Rhs) == 1 {
+				c.checkAssign(fb, n.Lhs[0], n.Rhs[0])
+			}
+		case *ast.ValueSpec:
+			if len(n.Names) == len(n.Values) {
+				for i := range n.Names {
+					c.checkAssign(fb, n.Names[i], n.Values[i])
+				}
+			} else if len(n.Values) == 1 {
+				c.checkAssign(fb, n.Names[0], n.Values[0])
+			}
+		}
+		return true
+	})
+}
+
+// Get the name of the cypher method called: Run, RunMany or TX, or an empty string for any other call.
+func (c *checker) methodOf(call *ast.CallExpr) string {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	selection := c.info.Selections[sel]
+	if selection == nil || selection.Kind() != types.MethodVal {
+		return ""
+	}
+	recv := selection.Recv()
+	switch name := sel.Sel.Name; name {
+	case "Run", "RunMany":
+		if types.Implements(recv, c.runner) {
+			return name
+		}
+	case "TX":
+		if types.Implements(recv, c.db) {
+			return name
+		}
+	}
+	return ""
+}
+
+// Get the name of the function of package cypher which is called, if any.
+func (c *checker) funcOf(call *ast.CallExpr) string {
+	fun := ast.Unparen(call.Fun)
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+	var id *ast.Ident
+	switch f := fun.(type) {
+	case *ast.Ident:
+		id = f
+	case *ast.SelectorExpr:
+		id = f.Sel
+	default:
+		return ""
+	}
+	fn, ok := c.info.Uses[id].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != cypherPath {
+		return ""
+	}
+	if _, ok = statementFuncs[fn.Name()]; !ok {
+		return ""
+	}
+	return fn.Name()
+}
+
+// Check the statements and params of a call which runs statements.
+func (c *checker) checkCall(fb *funcBody, call *ast.CallExpr) {
+	switch c.methodOf(call) {
+	case "Run":
+		if len(call.Args) == 2 {
+			c.checkStatement(fb, call.Args[0], call.Args[1])
+		}
+		return
+	case "RunMany":
+		if call.Ellipsis.IsValid() {
+			return
+		}
+		for i, arg := range call.Args {
+			if !c.isString(arg) {
+				continue
+			}
+			var params ast.Expr
+			if i+1 < len(call.Args) && !c.isString(call.Args[i+1]) {
+				params = call.Args[i+1]
+			}
+			c.checkStatement(fb, arg, params)
+		}
+		return
+	}
+	if name := c.funcOf(call); name != "" {
+		i := statementFuncs[name]
+		if i+1 < len(call.Args) {
+			c.checkStatement(fb, call.Args[i], call.Args[i+1])
+		}
+	}
+}
+
+func (c *checker) isString(e ast.Expr) bool {
+	t := c.info.TypeOf(e)
+	if t == nil {
+		return false
+	}
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsString != 0
+}
+
+func (c *checker) constString(e ast.Expr) (string, bool) {
+	tv, ok := c.info.Types[e]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// Check a statement for being built dynamically, and its params for matching its $parameters.
+// The params may be nil when they are not given.
+func (c *checker) checkStatement(fb *funcBody, statement, params ast.Expr) {
+	s, ok := c.constString(statement)
+	if !ok {
+		if c.isDynamic(fb, statement, make(map[types.Object]bool)) {
+			c.report(statement.Pos(), "cypher statement is built from non-constant strings: "+
+				"pass values as $parameters to avoid injection")
+		}
+		return
+	}
+	if params == nil {
+		return
+	}
+	keys, ok := c.literalKeys(params)
+	if !ok {
+		return
+	}
+	refs := paramRefs(s)
+	referenced := make(map[string]bool, len(refs))
+	for _, name := range refs {
+		referenced[name] = true
+		if _, ok := keys[name]; !ok {
+			c.report(params.Pos(), "cypher statement references $%s, which is missing from the params", name)
+		}
+	}
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !referenced[name] {
+			c.report(keys[name], "param %q is not referenced by the cypher statement", name)
+		}
+	}
+}
+
+// Get the keys of a params map literal, or of nil, by the position of each key.
+// Returns false if the params are not a literal, or have keys which are not constant.
+func (c *checker) literalKeys(params ast.Expr) (map[string]token.Pos, bool) {
+	params = ast.Unparen(params)
+	if tv, ok := c.info.Types[params]; ok && tv.IsNil() {
+		return map[string]token.Pos{}, true
+	}
+	lit, ok := params.(*ast.CompositeLit)
+	if !ok {
+		return nil, false
+	}
+	if _, ok = c.info.TypeOf(lit).Underlying().(*types.Map); !ok {
+		return nil, false
+	}
+	keys := make(map[string]token.Pos, len(lit.Elts))
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			return nil, false
+		}
+		key, ok := c.constString(kv.Key)
+		if !ok {
+			return nil, false
+		}
+		keys[key] = kv.Key.Pos()
+	}
+	return keys, true
+}
+
+// Whether a string is built from non-constant values, by concatenation, formatting or joining,
+// directly or through a local variable.
+func (c *checker) isDynamic(fb *funcBody, e ast.Expr, seen map[types.Object]bool) bool {
+	e = ast.Unparen(e)
+	if _, ok := c.constString(e); ok {
+		return false
+	}
+	switch x := e.(type) {
+	case *ast.BinaryExpr:
+		return x.Op == token.ADD
+	case *ast.CallExpr:
+		if fn := c.calledFunc(x); fn != nil && fn.Pkg() != nil {
+			switch fn.Pkg().Path() + "." + fn.Name() {
+			case "fmt.Sprintf", "fmt.Sprint", "fmt.Sprintln",
+				"strings.Join", "strings.Replace", "strings.ReplaceAll", "strings.Repeat":
+				return true
+			}
+		}
+		if sel, ok := ast.Unparen(x.Fun).(*ast.SelectorExpr); ok && sel.Sel.Name == "String" {
+			if t := c.info.TypeOf(sel.X); t != nil {
+				switch types.TypeString(t, nil) {
+				case "*strings.Builder", "strings.Builder", "*bytes.Buffer", "bytes.Buffer":
+					return true
+				}
+			}
+		}
+	case *ast.Ident:
+		v, ok := c.info.Uses[x].(*types.Var)
+		if !ok || seen[v] || v.Pos() < fb.body.Pos() || v.Pos() > fb.body.End() {
+			return false
+		}
+		seen[v] = true
+		return c.assignedDynamic(fb, v, seen)
+	}
+	return false
+}
+
+// Whether a local variable is assigned a dynamic string anywhere in the function.
+func (c *checker) assignedDynamic(fb *funcBody, v *types.Var, seen map[types.Object]bool) bool {
+	dynamic := false
+	ast.Inspect(fb.body, func(n ast.Node) bool {
+		if dynamic {
+			return false
+		}
+		switch a := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range a.Lhs {
+				id, ok := lhs.(*ast.Ident)
+				if !ok || c.objectOf(id) != v || len(a.Lhs) != len(a.Rhs) {
+					continue
+				}
+				if a.Tok == token.ADD_ASSIGN {
+					_, constant := c.constString(a.Rhs[i])
+					dynamic = !constant
+				} else {
+					dynamic = c.isDynamic(fb, a.Rhs[i], seen)
+				}
+			}
+		case *ast.ValueSpec:
+			for i, id := range a.Names {
+				if c.objectOf(id) == v && len(a.Names) == len(a.Values) {
+					dynamic = c.isDynamic(fb, a.Values[i], seen)
+				}
+			}
+		}
+		return !dynamic
+	})
+	return dynamic
+}
+
+func (c *checker) objectOf(id *ast.Ident) types.Object {
+	if obj := c.info.Defs[id]; obj != nil {
+		return obj
+	}
+	return c.info.Uses[id]
+}
+
+func (c *checker) calledFunc(call *ast.CallExpr) *types.Func {
+	var id *ast.Ident
+	switch f := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		id = f
+	case *ast.SelectorExpr:
+		id = f.Sel
+	default:
+		return nil
+	}
+	fn, _ := c.info.Uses[id].(*types.Func)
+	return fn
+}
+
+// Report results and responses which are discarded, and errors of Consume which are ignored.
+func (c *checker) checkDiscarded(stmt *ast.ExprStmt) {
+	call, ok := ast.Unparen(stmt.X).(*ast.CallExpr)
+	if !ok {
+		return
+	}
+	if name := c.methodOf(call); name == "Run" || name == "RunMany" || c.funcOf(call) == "RunWith" {
+		c.report(call.Pos(), "the %s returned here is discarded, so its error is never checked", c.kindOf(call))
+		return
+	}
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Consume" {
+		return
+	}
+	if inner, ok := ast.Unparen(sel.X).(*ast.CallExpr); ok {
+		if name := c.methodOf(inner); name == "Run" || name == "RunMany" || c.funcOf(inner) == "RunWith" {
+			c.report(call.Pos(), "the error of Consume is not checked")
+		}
+	}
+}
+
+// Describe the value returned by a call: a result or a response.
+func (c *checker) kindOf(call *ast.CallExpr) string {
+	if t := c.info.TypeOf(call); t != nil && c.response != nil && types.Identical(t, c.response) {
+		return "response"
+	}
+	return "result"
+}
+
+// Check the variables assigned results, responses and transactions.
+func (c *checker) checkAssign(fb *funcBody, lhs, rhs ast.Expr) {
+	call, ok := ast.Unparen(rhs).(*ast.CallExpr)
+	if !ok {
+		return
+	}
+	method := c.methodOf(call)
+	if method == "" && c.funcOf(call) == "RunWith" {
+		method = "Run"
+	}
+	if method == "" {
+		return
+	}
+	id, ok := lhs.(*ast.Ident)
+	if !ok {
+		return
+	}
+	if id.Name == "_" {
+		if method != "TX" {
+			c.report(call.Pos(), "the %s returned here is discarded, so its error is never checked", c.kindOf(call))
+		}
+		return
+	}
+	v, ok := c.objectOf(id).(*types.Var)
+	if !ok {
+		return
+	}
+	if method == "TX" {
+		if !c.used(fb, v, "Commit", "Rollback") {
+			c.report(id.Pos(), "transaction %s is never committed or rolled back", id.Name)
+		}
+		return
+	}
+	if !c.used(fb, v, "Err", "Consume") {
+		c.report(id.Pos(), "the error of %s %s is never checked with Err or Consume", c.kindOf(call), id.Name)
+	}
+}
+
+// Whether any of the methods are called on the variable, or it escapes the function,
+// such as by being passed to another function, returned or stored, in which case it is assumed to be used.
+func (c *checker) used(fb *funcBody, v *types.Var, methods ...string) bool {
+	found := false
+	ast.Inspect(fb.body, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		id, ok := n.(*ast.Ident)
+		if !ok || c.info.Uses[id] != v {
+			return true
+		}
+		switch parent := fb.parents[id].(type) {
+		case *ast.SelectorExpr:
+			for _, m := range methods {
+				if parent.Sel.Name == m {
+					found = true
+				}
+			}
+		case *ast.AssignStmt:
+			// Reassigning the variable does not use it, but assigning it to another does.
+			for _, lhs := range parent.Lhs {
+				if lhs == id {
+					return true
+				}
+			}
+			found = true
+		default:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+var wantPattern = regexp.MustCompile("// want (.*)$")
+var wantQuoted = regexp.MustCompile("\"(?:[^\"\\\\]|\\\\.)*\"|`[^`]*`")
+
+// Check the files of testdata/src/a, whose lines expect diagnostics matching the quoted patterns of their want comments.
+func TestChecker(t *testing.T) {
+	dir, err := filepath.Abs(filepath.Join("testdata", "src", "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []*ast.File
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			files = append(files, f)
+		}
+	}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	tc := &types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := tc.Check("a", fset, files, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newChecker(fset, pkg, info)
+	if c == nil {
+		t.Fatal("the package was not found to depend on package cypher")
+	}
+
+	type line struct {
+		file string
+		line int
+	}
+	wants := make(map[line][]*regexp.Regexp)
+	for _, f := range files {
+		c.checkFile(f)
+		for _, group := range f.Comments {
+			for _, comment := range group.List {
+				m := wantPattern.FindStringSubmatch(comment.Text)
+				if m == nil {
+					continue
+				}
+				pos := fset.Position(comment.Pos())
+				for _, quoted := range wantQuoted.FindAllString(m[1], -1) {
+					pattern, err := strconv.Unquote(quoted)
+					if err != nil {
+						t.Fatalf("%s: %v", pos, err)
+					}
+					key := line{pos.Filename, pos.Line}
+					wants[key] = append(wants[key], regexp.MustCompile(pattern))
+				}
+			}
+		}
+	}
+
+	for _, d := range c.sortedDiagnostics() {
+		key := line{d.pos.Filename, d.pos.Line}
+		matched := false
+		for i, want := range wants[key] {
+			if want.MatchString(d.message) {
+				wants[key] = append(wants[key][:i], wants[key][i+1:]...)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			t.Errorf("%s: unexpected diagnostic: %s", d.pos, d.message)
+		}
+	}
+	for key, patterns := range wants {
+		for _, want := range patterns {
+			t.Errorf("%s:%d: no diagnostic matching %q", key.file, key.line, want)
+		}
+	}
+}
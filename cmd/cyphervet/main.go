@@ -0,0 +1,235 @@
+// Command cyphervet reports suspicious uses of package cypher, as a tool for go vet.
+//
+// Usage:
+//
+//	go vet -vettool=$(which cyphervet) [packages]
+//	cyphervet [packages]
+//
+// When run directly, cyphervet runs go vet with itself as the vet tool.
+//
+// Calls of Run and RunMany on a cypher.Runner, and of cypher.Query, cypher.RunWith and cypher.RunAsync, are checked for:
+//   - statements built from non-constant strings, such as by concatenation or fmt.Sprintf, which risk injection
+//   - $parameters of constant statements which are missing from a literal params map, and params which are unused
+//   - results and responses whose errors are never checked with Err or Consume
+//
+// Transactions from DB.TX are checked for never being committed or rolled back.
+// Values which are passed to other functions, returned or stored are assumed to be checked elsewhere.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// The configuration given by go vet for each package, of which only the fields used here are listed.
+type config struct {
+	Compiler                  string
+	Dir                       string
+	ImportPath                string
+	GoVersion                 string
+	GoFiles                   []string
+	ImportMap                 map[string]string
+	PackageFile               map[string]string
+	VetxOnly                  bool
+	VetxOutput                string
+	SucceedOnTypecheckFailure bool
+	// The file to write json output to, if not stdout.
+	Stdout string
+}
+
+func main() {
+	version := flag.String("V", "", "print the version and exit")
+	printFlags := flag.Bool("flags", false, "print the flags as json and exit")
+	jsonOutput := flag.Bool("json", false, "print the diagnostics as json to stdout")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: cyphervet [packages]\n   or: go vet -vettool=$(which cyphervet) [packages]")
+	}
+	flag.Parse()
+	switch {
+	case *version != "":
+		printVersion()
+	case *printFlags:
+		// No flags are accepted from go vet.
+		fmt.Println("[]")
+	case flag.NArg() == 1 && strings.HasSuffix(flag.Arg(0), ".cfg"):
+		os.Exit(runUnit(flag.Arg(0), *jsonOutput))
+	default:
+		os.Exit(runVet(flag.Args()))
+	}
+}
+
+// Print the version in the form go vet expects, identifying this build of the tool for its cache.
+func printVersion() {
+	exe, err := os.Executable()
+	if err != nil {
+		fatalf("%v", err)
+	}
+	f, err := os.Open(exe)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("%s version devel comments-go-here buildID=%x\n", os.Args[0], h.Sum(nil))
+}
+
+// Run go vet on the packages with this executable as the vet tool.
+func runVet(packages []string) int {
+	exe, err := os.Executable()
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if len(packages) == 0 {
+		packages = []string{"."}
+	}
+	cmd := exec.Command("go", append([]string{"vet", "-vettool=" + exe}, packages...)...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err = cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode()
+		}
+		fatalf("%v", err)
+	}
+	return 0
+}
+
+// Check a single package as described by the configuration file given by go vet.
+// Newer versions of go vet request the diagnostics as json, in the form written by the analysis framework's unitchecker.
+func runUnit(cfgFile string, jsonOutput bool) int {
+	b, err := os.ReadFile(cfgFile)
+	if err != nil {
+		fatalf("failed to read vet config: %v", err)
+	}
+	var cfg config
+	if err = json.Unmarshal(b, &cfg); err != nil {
+		fatalf("failed to parse vet config %s: %v", cfgFile, err)
+	}
+	// Facts are not used, but go vet expects the file to be written.
+	if cfg.VetxOutput != "" {
+		if err = os.WriteFile(cfg.VetxOutput, nil, 0666); err != nil {
+			fatalf("failed to write facts: %v", err)
+		}
+	}
+	if cfg.VetxOnly {
+		return 0
+	}
+
+	fset := token.NewFileSet()
+	files := make([]*ast.File, 0, len(cfg.GoFiles))
+	for _, name := range cfg.GoFiles {
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			if cfg.SucceedOnTypecheckFailure {
+				return 0
+			}
+			fatalf("%v", err)
+		}
+		files = append(files, f)
+	}
+	compilerImporter := importer.ForCompiler(fset, cfg.Compiler, func(path string) (io.ReadCloser, error) {
+		file, ok := cfg.PackageFile[path]
+		if !ok {
+			return nil, fmt.Errorf("no package file for %q", path)
+		}
+		return os.Open(file)
+	})
+	tc := &types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if path == "unsafe" {
+				return types.Unsafe, nil
+			}
+			if mapped, ok := cfg.ImportMap[path]; ok {
+				path = mapped
+			}
+			return compilerImporter.Import(path)
+		}),
+		Sizes:     types.SizesFor(cfg.Compiler, build.Default.GOARCH),
+		GoVersion: cfg.GoVersion,
+	}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	pkg, err := tc.Check(cfg.ImportPath, fset, files, info)
+	if err != nil {
+		if cfg.SucceedOnTypecheckFailure {
+			return 0
+		}
+		fatalf("%v", err)
+	}
+
+	c := newChecker(fset, pkg, info)
+	if c == nil {
+		return 0
+	}
+	for _, f := range files {
+		c.checkFile(f)
+	}
+	diagnostics := c.sortedDiagnostics()
+	if jsonOutput {
+		printJSON(cfg.Stdout, cfg.ImportPath, diagnostics)
+		return 0
+	}
+	for _, d := range diagnostics {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", d.pos, d.message)
+	}
+	if len(diagnostics) > 0 {
+		return 1
+	}
+	return 0
+}
+
+type jsonDiagnostic struct {
+	Posn    string `json:"posn"`
+	Message string `json:"message"`
+}
+
+// Print the diagnostics of a package to stdout or to the file given, keyed by the package and the name of this analyzer.
+func printJSON(stdout, importPath string, diagnostics []diagnostic) {
+	if len(diagnostics) == 0 {
+		return
+	}
+	list := make([]jsonDiagnostic, len(diagnostics))
+	for i, d := range diagnostics {
+		list[i] = jsonDiagnostic{Posn: d.pos.String(), Message: d.message}
+	}
+	b, err := json.Marshal(map[string]map[string][]jsonDiagnostic{importPath: {"cyphervet": list}})
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if stdout == "" {
+		os.Stdout.Write(b)
+		return
+	}
+	if err = os.WriteFile(stdout, b, 0666); err != nil {
+		fatalf("failed to write diagnostics: %v", err)
+	}
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "cyphervet: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,73 @@
+package main
+
+import "strings"
+
+// Find the names of the $parameters referenced by a statement, in order of first reference.
+// Parameters within string literals and comments are ignored.
+func paramRefs(statement string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	s := statement
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == '\'' || ch == '"':
+			i = skipQuoted(s, i, ch)
+		case ch == '`':
+			i = skipQuoted(s, i, '`')
+		case ch == '/' && i+1 < len(s) && s[i+1] == '/':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case ch == '/' && i+1 < len(s) && s[i+1] == '*':
+			end := i + 2
+			for end+1 < len(s) && !(s[end] == '*' && s[end+1] == '/') {
+				end++
+			}
+			i = end + 1
+		case ch == '$' && i+1 < len(s):
+			var name string
+			if s[i+1] == '`' {
+				// An unclosed name runs to the end of the statement.
+				end := skipQuoted(s, i+1, '`')
+				name = strings.ReplaceAll(s[i+2:end], "``", "`")
+				i = end
+			} else {
+				end := i + 1
+				for end < len(s) && isIdentChar(s[end]) {
+					end++
+				}
+				name = s[i+1 : end]
+				i = end - 1
+			}
+			if name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// Returns the index of the quote which closes the quoted text starting at i, or len(s) if it is not closed.
+// Within backticks, a doubled backtick is an escaped backtick.
+func skipQuoted(s string, i int, quote byte) int {
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			if quote != '`' {
+				j++
+			}
+		case quote:
+			if quote == '`' && j+1 < len(s) && s[j+1] == '`' {
+				j++
+				continue
+			}
+			return j
+		}
+	}
+	return len(s)
+}
+
+func isIdentChar(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9'
+}
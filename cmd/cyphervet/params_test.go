@@ -0,0 +1,36 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParamRefs(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      []string
+	}{
+		{"RETURN 1", nil},
+		{"MATCH (n {name: $name}) SET n.age = $age RETURN $name", []string{"name", "age"}},
+		{"RETURN $a1, $_b, $1", []string{"a1", "_b", "1"}},
+		{"RETURN $`a name`", []string{"a name"}},
+		{"RETURN $`a``b`", []string{"a`b"}},
+		{"RETURN '$a', \"$b\", `$c`, 'it\\'s $d', $e", []string{"e"}},
+		{"RETURN $a // $b\n, $c", []string{"a", "c"}},
+		{"RETURN $a /* $b */, $c", []string{"a", "c"}},
+		// Unclosed quotes and comments run to the end of the statement.
+		{"RETURN $a, '$b", []string{"a"}},
+		{"RETURN $a /* $b", []string{"a"}},
+		{"RETURN $`", nil},
+		{"RETURN $`ab", []string{"ab"}},
+		{"RETURN $`a``", []string{"a`"}},
+		{"RETURN $", nil},
+		{"RETURN $ + 1", nil},
+		{"$", nil},
+	}
+	for _, test := range tests {
+		if got := paramRefs(test.statement); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("paramRefs(%q) = %q, expected %q", test.statement, got, test.want)
+		}
+	}
+}
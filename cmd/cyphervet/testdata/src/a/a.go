@@ -0,0 +1,114 @@
+package a
+
+import (
+	"fmt"
+	"github.com/tjbrockmeyer/cypher"
+	"strings"
+)
+
+func statements(db cypher.DB, name string, labels []string) {
+	_, _ = db.Run("MATCH (n {name: '"+name+"'}) RETURN n", nil).Consume()            // want "built from non-constant strings"
+	_, _ = db.Run(fmt.Sprintf("MATCH (n:%s) RETURN n", name), nil).Consume()         // want "built from non-constant strings"
+	_, _ = db.Run("MATCH (n:"+strings.Join(labels, ":")+") RETURN n", nil).Consume() // want "built from non-constant strings"
+	_, _ = cypher.RunWith(db, cypher.RunOptions{}, "RETURN "+name, nil).Consume()    // want "built from non-constant strings"
+	_, _ = cypher.Query[int](db, fmt.Sprint("RETURN ", name), nil)                   // want "built from non-constant strings"
+	_ = db.RunMany("RETURN 1", nil, fmt.Sprintf("RETURN %s", name), nil).Consume()   // want "built from non-constant strings"
+	statement := "MATCH (n) "
+	statement += "WHERE n.name = '" + name + "' "
+	statement += "RETURN n"
+	_, _ = db.Run(statement, nil).Consume() // want "built from non-constant strings"
+
+	var b strings.Builder
+	b.WriteString("RETURN 1")
+	_, _ = db.Run(b.String(), nil).Consume() // want "built from non-constant strings"
+
+	// Constant statements, and statements from elsewhere, are not reported.
+	const constant = "MATCH (n) "
+	_, _ = db.Run(constant+"RETURN n", nil).Consume()
+	built := constant
+	built += "RETURN n"
+	_, _ = db.Run(built, nil).Consume()
+	_, _ = db.Run(name, nil).Consume()
+}
+
+func params(db cypher.DB, params map[string]interface{}) {
+	_, _ = db.Run("MATCH (n {name: $name}) RETURN n", map[string]interface{}{"name": 1}).Consume()
+	_, _ = db.Run("MATCH (n {name: $name}) RETURN n", nil).Consume()                               // want `references \$name, which is missing`
+	_, _ = db.Run("MATCH (n {name: $name}) RETURN n", map[string]interface{}{"nmae": 1}).Consume() // want `references \$name, which is missing` `param "nmae" is not referenced`
+	_, _ = db.Run("RETURN 1", map[string]interface{}{"unused": 1}).Consume()                       // want `param "unused" is not referenced`
+	_, _ = db.Run("RETURN $`a name`, $`a``b`", map[string]interface{}{"a name": 1, "a`b": 2}).Consume()
+	_, _ = db.Run("RETURN '$quoted', \"$quoted\", `$quoted` // $comment", map[string]interface{}{}).Consume()
+	_, _ = db.Run("RETURN 1 /* $comment */ + $n", map[string]interface{}{"n": 1}).Consume()
+	_, _ = cypher.RunWith(db, cypher.RunOptions{}, "RETURN $a", map[string]interface{}{"b": 1}).Consume()                          // want `references \$a, which is missing` `param "b" is not referenced`
+	_ = cypher.RunAsync(db, "RETURN $a", nil)                                                                                      // want `references \$a, which is missing`
+	_, _ = cypher.Query[int](db, "RETURN $a", map[string]interface{}{})                                                            // want `references \$a, which is missing`
+	_ = db.RunMany("RETURN $a", map[string]interface{}{"a": 1}, "RETURN $b", "RETURN 1", map[string]interface{}{"c": 1}).Consume() // want `param "c" is not referenced`
+
+	// Params which are not a literal are not checked.
+	_, _ = db.Run("RETURN $a", params).Consume()
+}
+
+func results(db cypher.DB) (cypher.Result, error) {
+	db.Run("RETURN 1", nil)                                  // want "result returned here is discarded"
+	db.RunMany("RETURN 1")                                   // want "response returned here is discarded"
+	cypher.RunWith(db, cypher.RunOptions{}, "RETURN 1", nil) // want "result returned here is discarded"
+	_ = db.Run("RETURN 1", nil)                              // want "result returned here is discarded"
+	db.Run("RETURN 1", nil).Consume()                        // want "error of Consume is not checked"
+	db.RunMany("RETURN 1").Consume()                         // want "error of Consume is not checked"
+
+	unchecked := db.Run("RETURN 1", nil) // want "error of result unchecked is never checked"
+	unchecked.NextRow()
+	uncheckedResponse := db.RunMany("RETURN 1") // want "error of response uncheckedResponse is never checked"
+	uncheckedResponse.NextResult()
+
+	// Results which are checked, or escape the function, are not reported.
+	checked := db.Run("RETURN 1", nil)
+	for checked.NextRow() {
+	}
+	if err := checked.Err(); err != nil {
+		return nil, err
+	}
+	consumed := db.RunMany("RETURN 1")
+	if err := consumed.Consume(); err != nil {
+		return nil, err
+	}
+	passed := db.Run("RETURN 1", nil)
+	if _, err := cypher.Collect(passed); err != nil {
+		return nil, err
+	}
+	returned := db.Run("RETURN 1", nil)
+	return returned, nil
+}
+
+func transactions(db cypher.DB) error {
+	uncommitted, err := db.TX() // want "transaction uncommitted is never committed or rolled back"
+	if err != nil {
+		return err
+	}
+	_, _ = uncommitted.Run("RETURN 1", nil).Consume()
+
+	committed, err := db.TX()
+	if err != nil {
+		return err
+	}
+	_, _ = committed.Run("RETURN 1", nil).Consume()
+	if err = committed.Commit(); err != nil {
+		return err
+	}
+
+	rolledBack, err := db.TX()
+	if err != nil {
+		return err
+	}
+	defer rolledBack.Rollback()
+
+	passed, err := db.TX()
+	if err != nil {
+		return err
+	}
+	return commit(passed)
+}
+
+func commit(tx cypher.Transaction) error {
+	return tx.Commit()
+}
@@ -0,0 +1,310 @@
+// Package csvimport loads local csv files into neo4j through parameterized, batched statements.
+// Columns are mapped to the properties of nodes or relationships by a declarative Mapping.
+package csvimport
+
+import (
+	"encoding/csv"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+type Options struct {
+	// The number of rows sent in each statement. Defaults to 1000.
+	BatchSize int
+	// Rows which could not be converted or imported are written here as csv,
+	// with the original columns followed by an error column. Rejected rows are discarded when nil.
+	Rejects io.Writer
+	// Called after each batch has been run.
+	Progress func(Progress)
+}
+
+type Progress struct {
+	// The number of rows read from the csv file, excluding the header.
+	Read int
+	// The number of rows successfully imported.
+	// For relationships, these are the rows whose endpoints were both matched.
+	Imported int
+	// The number of rows written to the rejects.
+	Rejected int
+}
+
+// Import all rows of the csv, which must start with a header row, using the given mapping.
+// Rows which fail to convert or import are rejected without stopping the import,
+// as are relationship rows whose endpoints do not match any node.
+// An error is returned only if the csv cannot be read or does not match the mapping.
+func Import(runner cypher.Runner, r io.Reader, m *Mapping, opts Options) (Progress, error) {
+	var progress Progress
+	if err := m.validate(); err != nil {
+		return progress, err
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	reader := csv.NewReader(r)
+	if m.Delimiter != "" {
+		reader.Comma, _ = utf8.DecodeRuneInString(m.Delimiter)
+	}
+	header, err := reader.Read()
+	if err != nil {
+		return progress, errors.WithMessage(err, "cypher/csvimport: failed to read the header")
+	}
+	im := &importer{
+		runner:    runner,
+		mapping:   m,
+		statement: buildStatement(m),
+		columns:   make(map[string]int, len(header)),
+		opts:      opts,
+		progress:  &progress,
+	}
+	for i, column := range header {
+		im.columns[column] = i
+	}
+	if err = im.checkColumns(); err != nil {
+		return progress, err
+	}
+	if opts.Rejects != nil {
+		im.rejects = csv.NewWriter(opts.Rejects)
+		if err = im.rejects.Write(append(header, "error")); err != nil {
+			return progress, errors.WithMessage(err, "cypher/csvimport: failed to write rejects")
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return progress, errors.WithMessagef(err, "cypher/csvimport: failed to read row %v", progress.Read+1)
+		}
+		progress.Read++
+		param, err := im.convertRecord(record)
+		if err != nil {
+			if err = im.reject(record, err); err != nil {
+				return progress, err
+			}
+			continue
+		}
+		if im.mapping.Relationship != nil {
+			param["line"] = len(im.batch)
+		}
+		im.batch = append(im.batch, param)
+		im.records = append(im.records, record)
+		if len(im.batch) >= opts.BatchSize {
+			if err = im.flush(); err != nil {
+				return progress, err
+			}
+		}
+	}
+	if err = im.flush(); err != nil {
+		return progress, err
+	}
+	if im.rejects != nil {
+		im.rejects.Flush()
+		return progress, errors.WithMessage(im.rejects.Error(), "cypher/csvimport: failed to write rejects")
+	}
+	return progress, nil
+}
+
+type importer struct {
+	runner    cypher.Runner
+	mapping   *Mapping
+	statement string
+	columns   map[string]int
+	opts      Options
+	progress  *Progress
+	rejects   *csv.Writer
+
+	batch   []interface{}
+	records [][]string
+}
+
+func (im *importer) checkColumns() error {
+	check := func(column string) error {
+		if _, ok := im.columns[column]; !ok {
+			return errors.New("cypher/csvimport: mapped column is missing from the csv header: " + column)
+		}
+		return nil
+	}
+	for _, p := range im.mapping.Properties {
+		if err := check(p.Column); err != nil {
+			return err
+		}
+	}
+	if r := im.mapping.Relationship; r != nil {
+		if err := check(r.From.Column); err != nil {
+			return err
+		}
+		return check(r.To.Column)
+	}
+	return nil
+}
+
+// Convert a csv record into the parameter for a single row of the statement.
+func (im *importer) convertRecord(record []string) (map[string]interface{}, error) {
+	props := make(map[string]interface{}, len(im.mapping.Properties))
+	for name, p := range im.mapping.Properties {
+		v, err := convert(record[im.columns[p.Column]], p.Type, p.Format, p.Separator)
+		if err != nil {
+			return nil, errors.WithMessage(err, "property "+name)
+		}
+		props[name] = v
+	}
+	if n := im.mapping.Node; n != nil {
+		for _, key := range n.Key {
+			if props[key] == nil {
+				return nil, errors.New("key property " + key + " is empty")
+			}
+		}
+	}
+	param := map[string]interface{}{"p": props}
+	if r := im.mapping.Relationship; r != nil {
+		for key, e := range map[string]Endpoint{"from": r.From, "to": r.To} {
+			v, err := convert(record[im.columns[e.Column]], e.Type, e.Format, "")
+			if err != nil {
+				return nil, errors.WithMessage(err, key+" endpoint")
+			}
+			if v == nil {
+				return nil, errors.New(key + " endpoint is empty")
+			}
+			param[key] = v
+		}
+	}
+	return param, nil
+}
+
+// Run the current batch, rejecting all of its rows if it fails,
+// and rejecting the relationship rows which were not created.
+func (im *importer) flush() error {
+	if len(im.batch) == 0 {
+		return nil
+	}
+	result := im.runner.Run(im.statement, map[string]interface{}{"rows": im.batch})
+	var created []bool
+	var err error
+	if im.mapping.Relationship != nil {
+		created, err = createdLines(result, len(im.batch))
+	} else {
+		_, err = result.Consume()
+	}
+	if err != nil {
+		for _, record := range im.records {
+			if err := im.reject(record, err); err != nil {
+				return err
+			}
+		}
+	} else if created != nil {
+		for i, record := range im.records {
+			if created[i] {
+				im.progress.Imported++
+			} else if err := im.reject(record, errNoEndpoints); err != nil {
+				return err
+			}
+		}
+	} else {
+		im.progress.Imported += len(im.batch)
+	}
+	// The runner may keep the params, so the next batch does not reuse them.
+	im.batch = nil
+	im.records = im.records[:0]
+	if im.opts.Progress != nil {
+		im.opts.Progress(*im.progress)
+	}
+	return nil
+}
+
+var errNoEndpoints = errors.New("the from or to node was not found")
+
+// Read the lines of a batch returned by a relationship statement, which are those whose relationships were created.
+func createdLines(result cypher.Result, n int) ([]bool, error) {
+	created := make([]bool, n)
+	for result.NextRow() {
+		line, ok := cypher.AsInt64(result.GetRow().GetAt(0))
+		if ok && line >= 0 && line < int64(n) {
+			created[line] = true
+		}
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	_, err := result.Consume()
+	return created, err
+}
+
+func (im *importer) reject(record []string, reason error) error {
+	im.progress.Rejected++
+	if im.rejects == nil {
+		return nil
+	}
+	return errors.WithMessage(im.rejects.Write(append(record, reason.Error())),
+		"cypher/csvimport: failed to write rejects")
+}
+
+// Build the statement which imports a batch of rows given as the parameter $rows.
+func buildStatement(m *Mapping) string {
+	var b strings.Builder
+	b.WriteString("UNWIND $rows AS row\n")
+	isKey := make(map[string]bool)
+	variable := "n"
+	if n := m.Node; n != nil {
+		labels := ""
+		for _, label := range n.Labels {
+			labels += ":" + quote(label)
+		}
+		if len(n.Key) > 0 {
+			keys := make([]string, len(n.Key))
+			for i, key := range n.Key {
+				isKey[key] = true
+				keys[i] = quote(key) + ": " + valueExpr("row.p."+quote(key), m.Properties[key].Type)
+			}
+			b.WriteString("MERGE (n" + labels + " {" + strings.Join(keys, ", ") + "})\n")
+		} else {
+			b.WriteString("CREATE (n" + labels + ")\n")
+		}
+	} else {
+		r := m.Relationship
+		variable = "r"
+		b.WriteString("MATCH (a:" + quote(r.From.Label) + " {" + quote(r.From.Property) + ": " +
+			valueExpr("row.from", r.From.Type) + "})\n")
+		b.WriteString("MATCH (b:" + quote(r.To.Label) + " {" + quote(r.To.Property) + ": " +
+			valueExpr("row.to", r.To.Type) + "})\n")
+		verb := "CREATE"
+		if r.Merge {
+			verb = "MERGE"
+		}
+		b.WriteString(verb + " (a)-[r:" + quote(r.Type) + "]->(b)\n")
+	}
+	sets := make([]string, 0, len(m.Properties))
+	for _, name := range m.propertyNames() {
+		if !isKey[name] {
+			sets = append(sets, variable+"."+quote(name)+" = "+valueExpr("row.p."+quote(name), m.Properties[name].Type))
+		}
+	}
+	if len(sets) > 0 {
+		b.WriteString("SET " + strings.Join(sets, ", ") + "\n")
+	}
+	if m.Relationship != nil {
+		// Rows whose endpoints were not matched return nothing, so they can be rejected.
+		b.WriteString("RETURN DISTINCT row.line AS line")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Wrap a parameter reference in the conversion required by its type.
+func valueExpr(ref, typ string) string {
+	switch elem, isList := elemType(typ); {
+	case elem == "date" && isList:
+		return "[x IN " + ref + " | date(x)]"
+	case elem == "date":
+		return "date(" + ref + ")"
+	default:
+		return ref
+	}
+}
+
+func quote(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
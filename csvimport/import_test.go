@@ -0,0 +1,160 @@
+package csvimport
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher/internal/cyphertest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var people = &Mapping{
+	Node: &NodeMapping{Labels: []string{"Person", "Us`er"}, Key: []string{"id"}},
+	Properties: map[string]Property{
+		"id":   {Column: "person_id", Type: "int"},
+		"name": {Column: "name"},
+		"born": {Column: "dob", Type: "date", Format: "01/02/2006"},
+		"tags": {Column: "tags", Type: "list<date>"},
+	},
+}
+
+var knows = &Mapping{
+	Relationship: &RelationshipMapping{
+		Type:  "KNOWS",
+		From:  Endpoint{Label: "Person", Property: "id", Column: "from", Type: "int"},
+		To:    Endpoint{Label: "Person", Property: "born", Column: "to", Type: "date"},
+		Merge: true,
+	},
+	Properties: map[string]Property{"since": {Column: "since", Type: "int"}},
+}
+
+func TestBuildStatement(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping *Mapping
+		want    string
+	}{
+		{"merged node", people, "UNWIND $rows AS row\n" +
+			"MERGE (n:`Person`:`Us``er` {`id`: row.p.`id`})\n" +
+			"SET n.`born` = date(row.p.`born`), n.`name` = row.p.`name`, n.`tags` = [x IN row.p.`tags` | date(x)]"},
+		{"created node", &Mapping{Node: &NodeMapping{Labels: []string{"Tag"}}}, "UNWIND $rows AS row\n" +
+			"CREATE (n:`Tag`)"},
+		{"relationship", knows, "UNWIND $rows AS row\n" +
+			"MATCH (a:`Person` {`id`: row.from})\n" +
+			"MATCH (b:`Person` {`born`: date(row.to)})\n" +
+			"MERGE (a)-[r:`KNOWS`]->(b)\n" +
+			"SET r.`since` = row.p.`since`\n" +
+			"RETURN DISTINCT row.line AS line"},
+	}
+	for _, test := range tests {
+		if got := buildStatement(test.mapping); got != test.want {
+			t.Errorf("%s: built\n%s\nexpected\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestImportRejects(t *testing.T) {
+	db := new(cyphertest.DB)
+	var rejects strings.Builder
+	var progress []Progress
+	csv := "person_id,name,dob,tags\n" +
+		"1,Alice,01/02/1990,2024-01-01;2024-02-01\n" +
+		"x,Bob,,\n" +
+		",Carol,,\n" +
+		"4,Dan,1990-01-02,\n" +
+		"5,Eve,,\n"
+	got, err := Import(db, strings.NewReader(csv), people, Options{BatchSize: 2, Rejects: &rejects,
+		Progress: func(p Progress) { progress = append(progress, p) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Progress{Read: 5, Imported: 2, Rejected: 3}); got != want {
+		t.Errorf("the progress is %+v, expected %+v", got, want)
+	}
+	// The batch is full once the fifth row is read.
+	if want := []Progress{{Read: 5, Imported: 2, Rejected: 3}}; !reflect.DeepEqual(progress, want) {
+		t.Errorf("progress was reported as %+v, expected %+v", progress, want)
+	}
+	// The rejects are the rows followed by the error, whose messages come from strconv and time.
+	wantRejects := []string{
+		"person_id,name,dob,tags,error",
+		`x,Bob,,,"property id: strconv.ParseInt: parsing ""x"": invalid syntax"`,
+		",Carol,,,key property id is empty",
+		`4,Dan,1990-01-02,,"property born: parsing time ""1990-01-02""`,
+	}
+	lines := strings.Split(strings.TrimSuffix(rejects.String(), "\n"), "\n")
+	if len(lines) != len(wantRejects) {
+		t.Fatalf("the rejects are\n%s", rejects.String())
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, wantRejects[i]) {
+			t.Errorf("line %d of the rejects is %s, expected %s", i, line, wantRejects[i])
+		}
+	}
+	runs := db.Runs()
+	if len(runs) != 1 {
+		t.Fatalf("ran %d statements, expected 1", len(runs))
+	}
+	wantRows := []interface{}{
+		map[string]interface{}{"p": map[string]interface{}{
+			"id": int64(1), "name": "Alice", "born": "1990-01-02", "tags": []interface{}{"2024-01-01", "2024-02-01"}}},
+		map[string]interface{}{"p": map[string]interface{}{"id": int64(5), "name": "Eve", "born": nil, "tags": nil}},
+	}
+	if !reflect.DeepEqual(runs[0].Params["rows"], wantRows) {
+		t.Errorf("the first batch is %#v, expected %#v", runs[0].Params["rows"], wantRows)
+	}
+}
+
+func TestImportRelationshipRejects(t *testing.T) {
+	batch := 0
+	db := &cyphertest.DB{Respond: func(statement string, params map[string]interface{}) *cyphertest.Result {
+		batch++
+		switch batch {
+		case 1:
+			// Only the second row of the first batch found both of its endpoints.
+			return cyphertest.NewResult([]string{"line"}, []interface{}{int64(1)})
+		default:
+			result := cyphertest.NewResult([]string{"line"})
+			result.Error = errors.New("deadlock")
+			return result
+		}
+	}}
+	var rejects strings.Builder
+	csv := "from,to,since\n" +
+		"1,2000-01-01,2001\n" +
+		"2,2000-01-02,2002\n" +
+		"3,,2003\n" +
+		"4,2000-01-04,2004\n"
+	got, err := Import(db, strings.NewReader(csv), knows, Options{BatchSize: 2, Rejects: &rejects})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Progress{Read: 4, Imported: 1, Rejected: 3}); got != want {
+		t.Errorf("the progress is %+v, expected %+v", got, want)
+	}
+	wantRejects := "from,to,since,error\n" +
+		"1,2000-01-01,2001,the from or to node was not found\n" +
+		"3,,2003,to endpoint is empty\n" +
+		"4,2000-01-04,2004,deadlock\n"
+	if rejects.String() != wantRejects {
+		t.Errorf("the rejects are\n%s\nexpected\n%s", rejects.String(), wantRejects)
+	}
+	runs := db.Runs()
+	if len(runs) != 2 {
+		t.Fatalf("ran %d statements, expected 2", len(runs))
+	}
+	wantRows := []interface{}{
+		map[string]interface{}{"p": map[string]interface{}{"since": int64(2001)}, "from": int64(1), "to": "2000-01-01", "line": 0},
+		map[string]interface{}{"p": map[string]interface{}{"since": int64(2002)}, "from": int64(2), "to": "2000-01-02", "line": 1},
+	}
+	if !reflect.DeepEqual(runs[0].Params["rows"], wantRows) {
+		t.Errorf("the first batch is %#v, expected %#v", runs[0].Params["rows"], wantRows)
+	}
+}
+
+func TestImportMissingColumn(t *testing.T) {
+	_, err := Import(new(cyphertest.DB), strings.NewReader("person_id,name\n1,Alice\n"), people, Options{})
+	if err == nil || !strings.Contains(err.Error(), "missing from the csv header") {
+		t.Errorf("a missing column was not reported: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package csvimport
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mapping declares how the columns of a csv file become nodes or relationships.
+// Exactly one of Node or Relationship must be set.
+//
+//	{
+//	  "node": {"labels": ["Person"], "key": ["id"]},
+//	  "properties": {
+//	    "id":   {"column": "person_id", "type": "int"},
+//	    "born": {"column": "dob", "type": "date", "format": "01/02/2006"},
+//	    "tags": {"column": "tags", "type": "list<string>", "separator": ";"}
+//	  }
+//	}
+type Mapping struct {
+	// The field delimiter of the csv file. Defaults to a comma.
+	Delimiter    string               `json:"delimiter"`
+	Node         *NodeMapping         `json:"node"`
+	Relationship *RelationshipMapping `json:"relationship"`
+	// The properties to set on each node or relationship, keyed by property name.
+	Properties map[string]Property `json:"properties"`
+}
+
+type NodeMapping struct {
+	Labels []string `json:"labels"`
+	// The properties which identify the node. When set, nodes are merged on these properties instead of created.
+	Key []string `json:"key"`
+}
+
+type RelationshipMapping struct {
+	Type string   `json:"type"`
+	From Endpoint `json:"from"`
+	To   Endpoint `json:"to"`
+	// Merge the relationship between the endpoints instead of always creating a new one.
+	Merge bool `json:"merge"`
+}
+
+// Endpoint identifies an existing node by a single property, whose value is read from a column.
+type Endpoint struct {
+	Label    string `json:"label"`
+	Property string `json:"property"`
+	Column   string `json:"column"`
+	Type     string `json:"type"`
+	Format   string `json:"format"`
+}
+
+type Property struct {
+	Column string `json:"column"`
+	// One of string, int, float, bool, date or list<type>. Defaults to string.
+	Type string `json:"type"`
+	// The layout of date values, as used by time.Parse. Defaults to 2006-01-02.
+	Format string `json:"format"`
+	// The separator of list values. Defaults to a semicolon.
+	Separator string `json:"separator"`
+}
+
+// Read a json mapping file.
+func LoadMapping(path string) (*Mapping, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "cypher/csvimport: failed to read mapping file")
+	}
+	m := new(Mapping)
+	if err = json.Unmarshal(b, m); err != nil {
+		return nil, errors.WithMessage(err, "cypher/csvimport: failed to parse mapping file "+path)
+	}
+	return m, m.validate()
+}
+
+func (m *Mapping) validate() error {
+	if (m.Node == nil) == (m.Relationship == nil) {
+		return errors.New("cypher/csvimport: mapping must declare exactly one of node or relationship")
+	}
+	if m.Node != nil {
+		if len(m.Node.Labels) == 0 {
+			return errors.New("cypher/csvimport: node mapping must declare at least one label")
+		}
+		for _, key := range m.Node.Key {
+			if _, ok := m.Properties[key]; !ok {
+				return errors.New("cypher/csvimport: node key is not a mapped property: " + key)
+			}
+		}
+	}
+	if r := m.Relationship; r != nil {
+		if r.Type == "" {
+			return errors.New("cypher/csvimport: relationship mapping must declare a type")
+		}
+		for _, e := range []Endpoint{r.From, r.To} {
+			if e.Label == "" || e.Property == "" || e.Column == "" {
+				return errors.New("cypher/csvimport: relationship endpoints must declare a label, property and column")
+			}
+			if err := checkType(e.Type); err != nil {
+				return err
+			}
+		}
+	}
+	for name, p := range m.Properties {
+		if p.Column == "" {
+			return errors.New("cypher/csvimport: property must declare a column: " + name)
+		}
+		if err := checkType(p.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The property names, sorted so that generated statements are stable.
+func (m *Mapping) propertyNames() []string {
+	names := make([]string, 0, len(m.Properties))
+	for name := range m.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func elemType(typ string) (elem string, isList bool) {
+	if strings.HasPrefix(typ, "list<") && strings.HasSuffix(typ, ">") {
+		return typ[len("list<") : len(typ)-1], true
+	}
+	return typ, false
+}
+
+func checkType(typ string) error {
+	elem, _ := elemType(typ)
+	switch elem {
+	case "", "string", "int", "float", "bool", "date":
+		return nil
+	default:
+		return errors.New("cypher/csvimport: unknown type: " + typ)
+	}
+}
+
+// Convert a csv value into a parameter value of the given type. Empty values become nil.
+// Dates are validated and converted to ISO-8601, to be passed to the cypher date() function.
+func convert(value, typ, format, separator string) (interface{}, error) {
+	if value == "" {
+		return nil, nil
+	}
+	elem, isList := elemType(typ)
+	if !isList {
+		return convertScalar(value, elem, format)
+	}
+	if separator == "" {
+		separator = ";"
+	}
+	parts := strings.Split(value, separator)
+	list := make([]interface{}, len(parts))
+	for i, part := range parts {
+		v, err := convertScalar(strings.TrimSpace(part), elem, format)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = v
+	}
+	return list, nil
+}
+
+func convertScalar(value, typ, format string) (interface{}, error) {
+	switch typ {
+	case "int":
+		return strconv.ParseInt(value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	case "bool":
+		return strconv.ParseBool(value)
+	case "date":
+		if format == "" {
+			format = "2006-01-02"
+		}
+		t, err := time.Parse(format, value)
+		if err != nil {
+			return nil, err
+		}
+		return t.Format("2006-01-02"), nil
+	default:
+		return value, nil
+	}
+}
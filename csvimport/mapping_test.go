@@ -0,0 +1,71 @@
+package csvimport
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		value, typ, format, separator string
+		want                          interface{}
+		err                           bool
+	}{
+		{value: "", typ: "int", want: nil},
+		{value: "a", typ: "", want: "a"},
+		{value: "a", typ: "string", want: "a"},
+		{value: "-12", typ: "int", want: int64(-12)},
+		{value: "9007199254740993", typ: "int", want: int64(9007199254740993)},
+		{value: "1.5", typ: "int", err: true},
+		{value: "1.5", typ: "float", want: 1.5},
+		{value: "x", typ: "float", err: true},
+		{value: "true", typ: "bool", want: true},
+		{value: "yes", typ: "bool", err: true},
+		{value: "2024-01-02", typ: "date", want: "2024-01-02"},
+		{value: "01/02/2024", typ: "date", format: "01/02/2006", want: "2024-01-02"},
+		{value: "2024-13-01", typ: "date", err: true},
+		{value: "a;b", typ: "list<string>", want: []interface{}{"a", "b"}},
+		{value: "1, 2 ,3", typ: "list<int>", separator: ",", want: []interface{}{int64(1), int64(2), int64(3)}},
+		{value: "1;x", typ: "list<int>", err: true},
+		{value: "2024-01-02;2024-02-03", typ: "list<date>", want: []interface{}{"2024-01-02", "2024-02-03"}},
+	}
+	for _, test := range tests {
+		got, err := convert(test.value, test.typ, test.format, test.separator)
+		if (err != nil) != test.err {
+			t.Errorf("convert(%q, %q) returned the error %v", test.value, test.typ, err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("convert(%q, %q) = %#v, expected %#v", test.value, test.typ, got, test.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	endpoint := Endpoint{Label: "Person", Property: "id", Column: "id"}
+	tests := []struct {
+		name    string
+		mapping Mapping
+		valid   bool
+	}{
+		{"node", Mapping{Node: &NodeMapping{Labels: []string{"Person"}}}, true},
+		{"node and relationship", Mapping{Node: &NodeMapping{Labels: []string{"Person"}},
+			Relationship: &RelationshipMapping{Type: "KNOWS", From: endpoint, To: endpoint}}, false},
+		{"neither", Mapping{}, false},
+		{"node without labels", Mapping{Node: &NodeMapping{}}, false},
+		{"unmapped key", Mapping{Node: &NodeMapping{Labels: []string{"Person"}, Key: []string{"id"}}}, false},
+		{"relationship", Mapping{Relationship: &RelationshipMapping{Type: "KNOWS", From: endpoint, To: endpoint}}, true},
+		{"relationship without type", Mapping{Relationship: &RelationshipMapping{From: endpoint, To: endpoint}}, false},
+		{"incomplete endpoint", Mapping{Relationship: &RelationshipMapping{Type: "KNOWS", From: endpoint,
+			To: Endpoint{Label: "Person", Column: "id"}}}, false},
+		{"property without column", Mapping{Node: &NodeMapping{Labels: []string{"Person"}},
+			Properties: map[string]Property{"name": {}}}, false},
+		{"unknown type", Mapping{Node: &NodeMapping{Labels: []string{"Person"}},
+			Properties: map[string]Property{"name": {Column: "name", Type: "list<uuid>"}}}, false},
+	}
+	for _, test := range tests {
+		if err := test.mapping.validate(); (err == nil) != test.valid {
+			t.Errorf("%s: validate() returned %v", test.name, err)
+		}
+	}
+}
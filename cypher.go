@@ -1,11 +1,16 @@
 package cypher
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"github.com/pkg/errors"
+	"reflect"
+	"sync"
 )
 
 var drivers = make(map[string]Driver)
+var driversMu sync.RWMutex
 var Debug = false
 
 // Register a neo4j driver as some name.
@@ -14,6 +19,8 @@ func Register(name string, driver Driver) {
 	if driver == nil {
 		panic("driver cannot be nil: " + name)
 	}
+	driversMu.Lock()
+	defer driversMu.Unlock()
 	if _, ok := drivers[name]; ok {
 		panic("driver is already defined: " + name)
 	}
@@ -22,12 +29,17 @@ func Register(name string, driver Driver) {
 
 // Unregister a driver. This method will not panic.
 func Unregister(name string) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
 	delete(drivers, name)
 }
 
 // Connect to a database using a particular driver.
 func Connect(driverName, uri, dbName, username, password string) (DB, error) {
-	if d, ok := drivers[driverName]; ok {
+	driversMu.RLock()
+	d, ok := drivers[driverName]
+	driversMu.RUnlock()
+	if ok {
 		return d.Connect(uri, dbName, username, password)
 	} else {
 		return nil, errors.New("cypher: driver is not defined: " + driverName)
@@ -77,24 +89,104 @@ func SingleUnmarshal(result Result, asStruct interface{}) error {
 
 // Unmarshal a row into a given struct type.
 // Fields will be unmarshalled with the names of columns from the row.
+// Numbers in interface{} fields are represented as in the row, per its NumberMode.
+// Scanner fields scan the values of their columns, and a Scanner destination scans the value of a single column row.
 func UnmarshalRow(row Row, asStruct interface{}) error {
-	b, err := json.Marshal(row)
+	if scanner, ok := asStruct.(Scanner); ok && row.Len() == 1 {
+		return errors.WithMessage(scanner.ScanCypher(row.GetAt(0)), "failed to scan row")
+	}
+	if fields := scannerFields(reflect.ValueOf(asStruct)); len(fields) > 0 {
+		return unmarshalScanners(row, asStruct, fields)
+	}
+	mode := NumberModeOf(row)
+	b, err := marshalRow(row, mode)
 	if err != nil {
 		return errors.WithMessage(err, "failed to marshal row into json")
 	}
-	return errors.WithMessage(json.Unmarshal(b, &asStruct), "failed to unmarshal row into struct")
+	return errors.WithMessage(unmarshalNumbers(b, asStruct, mode), "failed to unmarshal row into struct")
 }
 
 // Unmarshall a list of rows into a given list of structs.
 // Fields will be unmarshalled with the names of columns from the row.
+// Numbers in interface{} fields are represented as in the rows, per the NumberMode of the first row.
 func UnmarshalRows(rows []Row, asStructSlice interface{}) error {
-	b, err := json.Marshal(rows)
+	if v := reflect.ValueOf(asStructSlice); v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Slice &&
+		hasScanners(v.Elem().Type().Elem()) {
+		return unmarshalRowsEach(rows, v.Elem())
+	}
+	mode := NumberFloat64
+	if len(rows) > 0 {
+		mode = NumberModeOf(rows[0])
+	}
+	marshaled := make([]json.RawMessage, len(rows))
+	for i, row := range rows {
+		b, err := marshalRow(row, mode)
+		if err != nil {
+			return errors.WithMessage(err, "failed to marshal rows into json")
+		}
+		marshaled[i] = b
+	}
+	b, err := json.Marshal(marshaled)
 	if err != nil {
 		return errors.WithMessage(err, "failed to marshal rows into json")
 	}
-	return errors.WithMessage(json.Unmarshal(b, &asStructSlice), "failed to unmarshal rows into struct slice")
+	return errors.WithMessage(unmarshalNumbers(b, asStructSlice, mode), "failed to unmarshal rows into struct slice")
+}
+
+// Unmarshal each row into an element of a new slice, replacing the given slice.
+func unmarshalRowsEach(rows []Row, slice reflect.Value) error {
+	values := reflect.MakeSlice(slice.Type(), len(rows), len(rows))
+	for i, row := range rows {
+		target := values.Index(i).Addr()
+		if elem := values.Index(i); elem.Kind() == reflect.Ptr {
+			elem.Set(reflect.New(elem.Type().Elem()))
+			target = elem
+		}
+		if err := UnmarshalRow(row, target.Interface()); err != nil {
+			return errors.WithMessagef(err, "failed to unmarshal row %v", i)
+		}
+	}
+	slice.Set(values)
+	return nil
+}
+
+// Marshal a row into json, as by marshalValues.
+func marshalRow(row Row, mode NumberMode) ([]byte, error) {
+	if mode != NumberInt64 {
+		return json.Marshal(row)
+	}
+	return marshalValues(row.AsMap(), mode)
+}
+
+// Marshal the values of a row into json. With NumberInt64, whole floats are marshaled with a fraction,
+// so that they are unmarshaled as float64 rather than int64, as they are in the row.
+func marshalValues(values map[string]interface{}, mode NumberMode) ([]byte, error) {
+	if mode == NumberInt64 {
+		values = fractionFloatsIn(values)
+	}
+	return json.Marshal(values)
+}
+
+func unmarshalNumbers(b []byte, v interface{}, mode NumberMode) error {
+	if mode == NumberFloat64 {
+		return json.Unmarshal(b, &v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	if mode != NumberJSON {
+		convertNumbersIn(reflect.ValueOf(v), mode)
+	}
+	return nil
 }
 
+// Drivers, databases and transactions are safe for concurrent use by multiple goroutines.
+// Statements run concurrently on the same transaction are serialized. A result which has not been fully read
+// when the next statement, or Commit, is sent on its transaction is first read into memory, and may still be read.
+// Rollback instead cancels such a result, and further reads of it fail.
+// Responses, results and rows are not safe for concurrent use, and should be read by a single goroutine.
 type Driver interface {
 	// Connect to a database.
 	Connect(uri, dbName, username, password string) (DB, error)
@@ -111,7 +203,17 @@ type DB interface {
 	// All queries which are run inside the provided QueryRunner will be run in the same transaction.
 	TXJob(func(runner Transaction) (interface{}, error)) (interface{}, error)
 
-	// Close the driver.
+	// Verify that the server is reachable, that the credentials are accepted and that the database is available.
+	Ping(ctx context.Context) error
+
+	// Information about the server, as discovered when connecting.
+	ServerInfo() ServerInfo
+
+	// The number of transactions opened with TX() which have not been committed or rolled back.
+	OpenTransactions() int
+
+	// Close the driver, rolling back any transactions which are still open.
+	// Their requests which are still being sent or read are cancelled rather than waited for.
 	Close() error
 }
 
@@ -125,6 +227,25 @@ type Runner interface {
 	RunMany(cypherOrParams ...interface{}) Response
 }
 
+// Options for running a single statement. See RunWith.
+type RunOptions struct {
+	// Ask the server for the nodes and relationships of each row, to be collected by Result.Graph.
+	Graph bool
+}
+
+// OptionsRunner is implemented by runners which accept options for each statement.
+type OptionsRunner interface {
+	RunWithOptions(opts RunOptions, cypher string, params map[string]interface{}) Result
+}
+
+// Run a statement with options if the runner accepts them, or with Run otherwise.
+func RunWith(runner Runner, opts RunOptions, cypher string, params map[string]interface{}) Result {
+	if r, ok := runner.(OptionsRunner); ok {
+		return r.RunWithOptions(opts, cypher, params)
+	}
+	return runner.Run(cypher, params)
+}
+
 type Transaction interface {
 	// Any transaction is capable of making queries.
 	Runner
@@ -149,12 +270,23 @@ type Response interface {
 
 	// Dispose of all results in the output.
 	Consume() error
+
+	// The notifications of the server about the statements.
+	// Available once the response has been consumed.
+	Notifications() []Notification
+
+	// The bookmarks of the transaction, if it was committed by this response and the server reports them.
+	// Available once the response has been consumed.
+	LastBookmarks() []string
 }
 
 type Result interface {
 	// The index of this result in the list of results returned from the server.
 	Index() int
 
+	// The names of the columns of this result, in the order returned from the server.
+	Columns() []string
+
 	// Returns true if another row has been read, false otherwise.
 	// The error should be checked after false is returned.
 	NextRow() bool
@@ -167,6 +299,10 @@ type Result interface {
 
 	// Discard all of the rows and get the stats.
 	Consume() (Stats, error)
+
+	// The nodes and relationships found in the rows read so far, including those read by Consume.
+	// Drivers which stream rows collect them only when RunOptions.Graph is requested, and the graph is empty otherwise.
+	Graph() *Graph
 }
 
 type Row interface {
@@ -178,6 +314,23 @@ type Row interface {
 
 	// Get a single column by name.
 	Get(n string) interface{}
+
+	// The column names of the row, in the order returned from the server.
+	Keys() []string
+
+	// The values of the row, in the same order as Keys().
+	Values() []interface{}
+
+	// The number of columns in the row.
+	Len() int
+
+	// Get the row as a map of column names to values.
+	AsMap() map[string]interface{}
+
+	// Get the metadata of the graph entities in a single column by index.
+	// The metadata mirrors the structure of the value: *Meta for a node or relationship,
+	// []interface{} for a list or path, and nil for any other value.
+	MetaAt(i int) interface{}
 }
 
 type Stats interface {
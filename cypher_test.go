@@ -0,0 +1,72 @@
+package cypher
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// A driver which connects to no database, counting its connections.
+type countingDriver struct {
+	mu       sync.Mutex
+	connects int
+}
+
+func (d *countingDriver) Connect(uri, dbName, username, password string) (DB, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.connects++
+	return nil, nil
+}
+
+// Run with -race to check that the registry is safe for concurrent use.
+func TestRegistryConcurrentUse(t *testing.T) {
+	shared := new(countingDriver)
+	Register("registry-test", shared)
+	t.Cleanup(func() { Unregister("registry-test") })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprint("registry-test-", i)
+			d := new(countingDriver)
+			for j := 0; j < 50; j++ {
+				Register(name, d)
+				if _, err := Connect(name, "", "", "", ""); err != nil {
+					t.Error(err)
+				}
+				if _, err := Connect("registry-test", "", "", "", ""); err != nil {
+					t.Error(err)
+				}
+				Unregister(name)
+				if _, err := Connect(name, "", "", "", ""); err == nil {
+					t.Errorf("connected to %s after it was unregistered", name)
+				}
+			}
+			if d.connects != 50 {
+				t.Errorf("%s was connected to %d times, expected 50", name, d.connects)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if shared.connects != 20*50 {
+		t.Errorf("the shared driver was connected to %d times, expected %d", shared.connects, 20*50)
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	Register("registry-test-twice", new(countingDriver))
+	t.Cleanup(func() { Unregister("registry-test-twice") })
+	for name, driver := range map[string]Driver{"registry-test-twice": new(countingDriver), "registry-test-nil": nil} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("registering %s did not panic", name)
+				}
+			}()
+			Register(name, driver)
+		}()
+	}
+}
@@ -0,0 +1,185 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type csvEncoder struct {
+	w             *csv.Writer
+	listSeparator string
+	record        []string
+}
+
+func newCSVEncoder(w io.Writer, listSeparator string) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w), listSeparator: listSeparator}
+}
+
+func (e *csvEncoder) header(names []string) error {
+	e.record = make([]string, len(names))
+	return e.w.Write(names)
+}
+
+func (e *csvEncoder) row(values []interface{}) error {
+	for i, v := range values {
+		s, err := e.format(v)
+		if err != nil {
+			return err
+		}
+		e.record[i] = s
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvEncoder) format(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []interface{}:
+		if e.listSeparator != "" && isScalarList(v) {
+			parts := make([]string, len(v))
+			for i, item := range v {
+				parts[i], _ = e.format(item)
+			}
+			return strings.Join(parts, e.listSeparator), nil
+		}
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+	b, err := json.Marshal(value)
+	return string(b), err
+}
+
+func isScalarList(list []interface{}) bool {
+	for _, item := range list {
+		switch item.(type) {
+		case map[string]interface{}, []interface{}:
+			return false
+		}
+	}
+	return true
+}
+
+func (e *csvEncoder) close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type jsonLinesEncoder struct {
+	w     *bufio.Writer
+	names [][]byte
+	buf   bytes.Buffer
+}
+
+func newJSONLinesEncoder(w io.Writer) *jsonLinesEncoder {
+	return &jsonLinesEncoder{w: bufio.NewWriter(w)}
+}
+
+func (e *jsonLinesEncoder) header(names []string) error {
+	e.names = make([][]byte, len(names))
+	for i, name := range names {
+		b, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		e.names[i] = b
+	}
+	return nil
+}
+
+func (e *jsonLinesEncoder) row(values []interface{}) error {
+	e.buf.Reset()
+	e.buf.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		e.buf.Write(e.names[i])
+		e.buf.WriteByte(':')
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		e.buf.Write(b)
+	}
+	e.buf.WriteString("}\n")
+	_, err := e.w.Write(e.buf.Bytes())
+	return err
+}
+
+func (e *jsonLinesEncoder) close() error {
+	return e.w.Flush()
+}
+
+// Writes one json object per line for each group of rows:
+//
+//	{"columns":["a","b"],"rows":2,"data":[[a1,a2],[b1,b2]]}
+type columnarEncoder struct {
+	w     *bufio.Writer
+	enc   *json.Encoder
+	size  int
+	group struct {
+		Columns []string        `json:"columns"`
+		Rows    int             `json:"rows"`
+		Data    [][]interface{} `json:"data"`
+	}
+}
+
+func newColumnarEncoder(w io.Writer, size int) *columnarEncoder {
+	e := &columnarEncoder{w: bufio.NewWriter(w), size: size}
+	e.enc = json.NewEncoder(e.w)
+	return e
+}
+
+func (e *columnarEncoder) header(names []string) error {
+	e.group.Columns = names
+	e.group.Data = make([][]interface{}, len(names))
+	for i := range e.group.Data {
+		e.group.Data[i] = make([]interface{}, 0, e.size)
+	}
+	return nil
+}
+
+func (e *columnarEncoder) row(values []interface{}) error {
+	for i, v := range values {
+		e.group.Data[i] = append(e.group.Data[i], v)
+	}
+	e.group.Rows++
+	if e.group.Rows >= e.size {
+		return e.flush()
+	}
+	return nil
+}
+
+func (e *columnarEncoder) flush() error {
+	if e.group.Rows == 0 {
+		return nil
+	}
+	if err := e.enc.Encode(&e.group); err != nil {
+		return err
+	}
+	e.group.Rows = 0
+	for i := range e.group.Data {
+		e.group.Data[i] = e.group.Data[i][:0]
+	}
+	return nil
+}
+
+func (e *columnarEncoder) close() error {
+	if err := e.flush(); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
@@ -0,0 +1,171 @@
+// Package export streams cypher results to files for use outside of neo4j.
+// Rows are written as they are read from the result, so results of any size may be exported.
+package export
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"io"
+	"sort"
+	"strings"
+)
+
+type Format int
+
+const (
+	// Comma separated values with a header row of column names.
+	CSV Format = iota
+	// One json object per line, keyed by column name.
+	JSONLines
+	// One json object per group of rows, holding an array of values for each column.
+	Columnar
+)
+
+// Parse the name of a format: csv, jsonl or columnar.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "csv":
+		return CSV, nil
+	case "jsonl", "jsonlines", "ndjson":
+		return JSONLines, nil
+	case "columnar":
+		return Columnar, nil
+	default:
+		return 0, errors.New("cypher/export: unknown format: " + name)
+	}
+}
+
+type Flatten int
+
+const (
+	// Nested values are kept as they are. Formats which cannot hold nested values encode them as json.
+	FlattenNone Flatten = iota
+	// Maps, including nodes and relationships, are expanded into a column per key, named column.key.
+	// The keys are taken from the first row, so keys which only appear in later rows are dropped.
+	FlattenDotted
+)
+
+type Options struct {
+	Format  Format
+	Flatten Flatten
+	// When not empty, lists of scalar values are joined by this separator instead of being encoded as json.
+	// Only applies to CSV.
+	ListSeparator string
+	// The number of rows in each group of the Columnar format. Defaults to 1000.
+	RowGroupSize int
+}
+
+// Write all rows of the result to w in the format given by the options, consuming the result.
+// The result is consumed even when writing fails. Returns the number of rows written.
+func Write(w io.Writer, result cypher.Result, opts Options) (int, error) {
+	var enc encoder
+	switch opts.Format {
+	case CSV:
+		enc = newCSVEncoder(w, opts.ListSeparator)
+	case JSONLines:
+		enc = newJSONLinesEncoder(w)
+	case Columnar:
+		size := opts.RowGroupSize
+		if size <= 0 {
+			size = 1000
+		}
+		enc = newColumnarEncoder(w, size)
+	default:
+		_, _ = result.Consume()
+		return 0, errors.Errorf("cypher/export: unknown format: %v", opts.Format)
+	}
+
+	count := 0
+	var fields []field
+	for result.NextRow() {
+		row := result.GetRow()
+		if fields == nil {
+			fields = makeFields(result.Columns(), row, opts.Flatten)
+			if err := enc.header(fieldNames(fields)); err != nil {
+				_, _ = result.Consume()
+				return count, errors.WithMessage(err, "cypher/export: failed to write header")
+			}
+		}
+		values := make([]interface{}, len(fields))
+		for i, f := range fields {
+			values[i] = f.value(row)
+		}
+		if err := enc.row(values); err != nil {
+			_, _ = result.Consume()
+			return count, errors.WithMessagef(err, "cypher/export: failed to write row %v", count)
+		}
+		count++
+	}
+	if _, err := result.Consume(); err != nil {
+		return count, errors.WithMessage(err, "cypher/export: failed to read rows")
+	}
+	if fields == nil {
+		if err := enc.header(result.Columns()); err != nil {
+			return count, errors.WithMessage(err, "cypher/export: failed to write header")
+		}
+	}
+	return count, errors.WithMessage(enc.close(), "cypher/export: failed to finish writing")
+}
+
+type encoder interface {
+	header(names []string) error
+	row(values []interface{}) error
+	close() error
+}
+
+// A single exported field: a column, or a value nested within a column.
+type field struct {
+	name   string
+	column int
+	path   []string
+}
+
+func (f field) value(row cypher.Row) interface{} {
+	v := row.GetAt(f.column)
+	for _, key := range f.path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+// Determine the exported fields from the columns and the first row of the result.
+func makeFields(columns []string, first cypher.Row, flatten Flatten) []field {
+	fields := make([]field, 0, len(columns))
+	for i, column := range columns {
+		if flatten == FlattenDotted {
+			fields = appendDotted(fields, column, i, nil, first.GetAt(i))
+		} else {
+			fields = append(fields, field{name: column, column: i})
+		}
+	}
+	return fields
+}
+
+func appendDotted(fields []field, name string, column int, path []string, value interface{}) []field {
+	m, ok := value.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		return append(fields, field{name: name, column: column, path: path})
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		p := append(append(make([]string, 0, len(path)+1), path...), key)
+		fields = appendDotted(fields, name+"."+key, column, p, m[key])
+	}
+	return fields
+}
+
+func fieldNames(fields []field) []string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	return names
+}
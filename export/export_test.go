@@ -0,0 +1,118 @@
+package export
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher/internal/cyphertest"
+	"strings"
+	"testing"
+)
+
+func people() *cyphertest.Result {
+	return cyphertest.NewResult([]string{"name", "n", "tags"},
+		[]interface{}{"Alice", map[string]interface{}{"age": 30.0, "city": "Paris"}, []interface{}{"a", "b"}},
+		[]interface{}{"Bob, Jr.", map[string]interface{}{"age": 1.5}, nil},
+		[]interface{}{"Carol", nil, []interface{}{map[string]interface{}{"x": 1.0}}})
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"csv", Options{Format: CSV}, `name,n,tags
+Alice,"{""age"":30,""city"":""Paris""}","[""a"",""b""]"
+"Bob, Jr.","{""age"":1.5}",
+Carol,,"[{""x"":1}]"
+`},
+		{"csv with a list separator", Options{Format: CSV, ListSeparator: "|"}, `name,n,tags
+Alice,"{""age"":30,""city"":""Paris""}",a|b
+"Bob, Jr.","{""age"":1.5}",
+Carol,,"[{""x"":1}]"
+`},
+		{"flattened csv", Options{Format: CSV, Flatten: FlattenDotted}, `name,n.age,n.city,tags
+Alice,30,Paris,"[""a"",""b""]"
+"Bob, Jr.",1.5,,
+Carol,,,"[{""x"":1}]"
+`},
+		{"json lines", Options{Format: JSONLines}, `{"name":"Alice","n":{"age":30,"city":"Paris"},"tags":["a","b"]}
+{"name":"Bob, Jr.","n":{"age":1.5},"tags":null}
+{"name":"Carol","n":null,"tags":[{"x":1}]}
+`},
+		{"flattened json lines", Options{Format: JSONLines, Flatten: FlattenDotted}, `{"name":"Alice","n.age":30,"n.city":"Paris","tags":["a","b"]}
+{"name":"Bob, Jr.","n.age":1.5,"n.city":null,"tags":null}
+{"name":"Carol","n.age":null,"n.city":null,"tags":[{"x":1}]}
+`},
+		{"columnar", Options{Format: Columnar, RowGroupSize: 2}, `{"columns":["name","n","tags"],"rows":2,"data":[["Alice","Bob, Jr."],[{"age":30,"city":"Paris"},{"age":1.5}],[["a","b"],null]]}
+{"columns":["name","n","tags"],"rows":1,"data":[["Carol"],[null],[[{"x":1}]]]}
+`},
+	}
+	for _, test := range tests {
+		var b strings.Builder
+		result := people()
+		count, err := Write(&b, result, test.opts)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if count != 3 {
+			t.Errorf("%s: wrote %d rows, expected 3", test.name, count)
+		}
+		if b.String() != test.want {
+			t.Errorf("%s: wrote\n%s\nexpected\n%s", test.name, b.String(), test.want)
+		}
+		if !result.Consumed() {
+			t.Errorf("%s: the result was not consumed", test.name)
+		}
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{CSV, "name,n\n"},
+		{JSONLines, ""},
+		{Columnar, ""},
+	}
+	for _, test := range tests {
+		var b strings.Builder
+		count, err := Write(&b, cyphertest.NewResult([]string{"name", "n"}), Options{Format: test.format})
+		if err != nil || count != 0 {
+			t.Errorf("format %v: wrote %d rows with the error %v", test.format, count, err)
+		}
+		if b.String() != test.want {
+			t.Errorf("format %v: wrote %q, expected %q", test.format, b.String(), test.want)
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriteErrorsConsumeResult(t *testing.T) {
+	for _, format := range []Format{CSV, JSONLines, Columnar, Format(-1)} {
+		// Enough rows to fill the buffers of the encoders.
+		rows := make([][]interface{}, 5000)
+		for i := range rows {
+			rows[i] = []interface{}{strings.Repeat("x", 100)}
+		}
+		result := cyphertest.NewResult([]string{"n"}, rows...)
+		if _, err := Write(failingWriter{}, result, Options{Format: format, RowGroupSize: 10}); err == nil {
+			t.Errorf("format %v: writing did not fail", format)
+		}
+		if !result.Consumed() {
+			t.Errorf("format %v: the result was not consumed after writing failed", format)
+		}
+	}
+
+	result := people()
+	result.Error = errors.New("connection reset")
+	if _, err := Write(new(strings.Builder), result, Options{Format: CSV}); errors.Cause(err) != result.Error {
+		t.Errorf("the error of the result was not returned: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package cypher
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+)
+
+// Run a query and collect all of its rows, unmarshaling each of them into a T.
+func Query[T any](runner Runner, cypher string, params map[string]interface{}) ([]T, error) {
+	return CollectAs[T](runner.Run(cypher, params))
+}
+
+// Collect all rows of the result, unmarshaling each of them into a T.
+func CollectAs[T any](result Result) ([]T, error) {
+	rows, err := Collect(result)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]T, len(rows))
+	for i, row := range rows {
+		if err = UnmarshalRow(row, &values[i]); err != nil {
+			return nil, errors.WithMessagef(err, "failed to unmarshal row %v", i)
+		}
+	}
+	return values, nil
+}
+
+// Get the first row of the result, unmarshaling it into a T and consuming the remainder of the result.
+// If there were no rows, the zero value of T is returned.
+func SingleAs[T any](result Result) (T, error) {
+	var value T
+	row, err := Single(result)
+	if err != nil || row == nil {
+		return value, err
+	}
+	return value, UnmarshalRow(row, &value)
+}
+
+// Collect a single column by name from every row of the result, unmarshaling each value into a T,
+// or scanning it if a *T is a Scanner.
+// The result is consumed if the column does not exist or a value cannot be unmarshaled.
+func ColumnAs[T any](result Result, name string) ([]T, error) {
+	index := -1
+	for i, column := range result.Columns() {
+		if column == name {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		if err := result.Err(); err != nil {
+			return nil, errors.WithMessage(err, "failed to collect column "+name)
+		}
+		_, _ = result.Consume()
+		return nil, errors.Errorf("result has no column %v", name)
+	}
+	values := make([]T, 0, 30)
+	for result.NextRow() {
+		var value T
+		if scanner, ok := any(&value).(Scanner); ok {
+			if err := scanner.ScanCypher(result.GetRow().GetAt(index)); err != nil {
+				_, _ = result.Consume()
+				return nil, errors.WithMessage(err, "failed to scan column "+name)
+			}
+			values = append(values, value)
+			continue
+		}
+		b, err := json.Marshal(result.GetRow().GetAt(index))
+		if err != nil {
+			_, _ = result.Consume()
+			return nil, errors.WithMessage(err, "failed to marshal column "+name+" into json")
+		}
+		if err = json.Unmarshal(b, &value); err != nil {
+			_, _ = result.Consume()
+			return nil, errors.WithMessage(err, "failed to unmarshal column "+name)
+		}
+		values = append(values, value)
+	}
+	return values, errors.WithMessage(result.Err(), "failed to collect column "+name)
+}
+
+// Run the given job in a single transaction on the database, returning its typed value.
+// See DB.TXJob for the semantics of committing and rolling back.
+func TXJobT[T any](db DB, job func(tx Transaction) (T, error)) (T, error) {
+	var value T
+	val, err := db.TXJob(func(tx Transaction) (interface{}, error) {
+		return job(tx)
+	})
+	if err != nil {
+		return value, err
+	}
+	if val != nil {
+		value = val.(T)
+	}
+	return value, nil
+}
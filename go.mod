@@ -1,5 +1,10 @@
 module github.com/tjbrockmeyer/cypher
 
-go 1.14
+go 1.23.0
 
-require github.com/pkg/errors v0.9.1
+require (
+	github.com/pkg/errors v0.9.1
+	golang.org/x/term v0.32.0
+)
+
+require golang.org/x/sys v0.33.0 // indirect
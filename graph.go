@@ -0,0 +1,202 @@
+package cypher
+
+import (
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+)
+
+// Meta identifies a node or relationship found in the value of a column.
+type Meta struct {
+	ID int64
+	// The element id of the node or relationship, for servers which report them (neo4j 5 and later).
+	ElementID string
+	// Either "node" or "relationship".
+	Type    string
+	Deleted bool
+}
+
+// A node of the graph.
+type Node struct {
+	ID         int64
+	ElementID  string
+	Labels     []string
+	Properties map[string]interface{}
+}
+
+// A directed relationship of the graph, from the start node to the end node.
+type Relationship struct {
+	ID             int64
+	ElementID      string
+	Type           string
+	StartID        int64
+	StartElementID string
+	EndID          int64
+	EndElementID   string
+	Properties     map[string]interface{}
+}
+
+// A set of nodes and relationships, deduplicated by their element ids, or their ids when they have no element ids.
+// The zero value is an empty graph.
+type Graph struct {
+	Nodes         []*Node
+	Relationships []*Relationship
+
+	nodeIndex map[string]bool
+	relIndex  map[string]bool
+}
+
+func identity(id int64, elementID string) string {
+	if elementID != "" {
+		return elementID
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+// Add a node, returning false if it is already in the graph.
+func (g *Graph) AddNode(n Node) bool {
+	key := identity(n.ID, n.ElementID)
+	if g.nodeIndex[key] {
+		return false
+	}
+	if g.nodeIndex == nil {
+		g.nodeIndex = make(map[string]bool)
+	}
+	g.nodeIndex[key] = true
+	g.Nodes = append(g.Nodes, &n)
+	return true
+}
+
+// Add a relationship, returning false if it is already in the graph.
+func (g *Graph) AddRelationship(r Relationship) bool {
+	key := identity(r.ID, r.ElementID)
+	if g.relIndex[key] {
+		return false
+	}
+	if g.relIndex == nil {
+		g.relIndex = make(map[string]bool)
+	}
+	g.relIndex[key] = true
+	g.Relationships = append(g.Relationships, &r)
+	return true
+}
+
+// Add the nodes, relationships and paths found in a value, including those within lists and maps.
+func (g *Graph) AddValue(value interface{}) {
+	switch v := value.(type) {
+	case Node:
+		g.AddNode(v)
+	case *Node:
+		g.AddNode(*v)
+	case Relationship:
+		g.AddRelationship(v)
+	case *Relationship:
+		g.AddRelationship(*v)
+	case Path:
+		for _, n := range v.Nodes {
+			g.AddNode(n)
+		}
+		for _, r := range v.Relationships {
+			g.AddRelationship(r)
+		}
+	case []interface{}:
+		for _, item := range v {
+			g.AddValue(item)
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			g.AddValue(item)
+		}
+	}
+}
+
+// A path through the graph, alternating between nodes and relationships.
+// There is always one more node than there are relationships.
+type Path struct {
+	Nodes         []Node
+	Relationships []Relationship
+}
+
+// A spatial point in the coordinate reference system identified by its SRID,
+// such as 4326 for WGS-84 or 7203 for 2D cartesian points.
+type Point struct {
+	SRID int
+	X    float64
+	Y    float64
+	Z    float64
+	// The point has a Z coordinate.
+	Is3D bool
+}
+
+// Format the point as extended well-known text, such as "SRID=4326;POINT (12.5 56.2)".
+func (p Point) String() string {
+	coords := []float64{p.X, p.Y}
+	if p.Is3D {
+		coords = append(coords, p.Z)
+	}
+	b := strings.Builder{}
+	b.WriteString("SRID=" + strconv.Itoa(p.SRID) + ";POINT")
+	if p.Is3D {
+		b.WriteString(" Z")
+	}
+	b.WriteString(" (")
+	for i, c := range coords {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(strconv.FormatFloat(c, 'g', -1, 64))
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// Parse a point from extended well-known text, as formatted by Point.String.
+func ParsePoint(s string) (Point, error) {
+	var p Point
+	srid, wkt, ok := strings.Cut(strings.TrimSpace(s), ";")
+	if !ok || !strings.HasPrefix(srid, "SRID=") {
+		return p, errors.New("cypher: point is missing its SRID: " + s)
+	}
+	var err error
+	if p.SRID, err = strconv.Atoi(srid[len("SRID="):]); err != nil {
+		return p, errors.WithMessage(err, "cypher: invalid point SRID")
+	}
+	open, end := strings.IndexByte(wkt, '('), strings.LastIndexByte(wkt, ')')
+	if !strings.HasPrefix(wkt, "POINT") || open < 0 || end < open {
+		return p, errors.New("cypher: invalid point: " + s)
+	}
+	fields := strings.Fields(wkt[open+1 : end])
+	if len(fields) != 2 && len(fields) != 3 {
+		return p, errors.New("cypher: point must have 2 or 3 coordinates: " + s)
+	}
+	coords := make([]float64, len(fields))
+	for i, f := range fields {
+		if coords[i], err = strconv.ParseFloat(f, 64); err != nil {
+			return p, errors.WithMessage(err, "cypher: invalid point coordinate")
+		}
+	}
+	p.X, p.Y = coords[0], coords[1]
+	if len(coords) == 3 {
+		p.Z, p.Is3D = coords[2], true
+	}
+	return p, nil
+}
+
+// A notification from the server about a statement, such as a warning about deprecated syntax.
+type Notification struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	// The category of the notification, for servers which report them (neo4j 5 and later).
+	Category    string `json:"category,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// The position in the statement which the notification refers to, if any.
+	Position *Position `json:"position,omitempty"`
+}
+
+// A position in a statement.
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
@@ -0,0 +1,198 @@
+// Package graphexport writes the nodes and relationships returned by a query as GraphML, GEXF or DOT,
+// for visualization in tools such as Gephi and Graphviz.
+package graphexport
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"sort"
+	"strconv"
+)
+
+// A deduplicated set of nodes and relationships.
+type Graph struct {
+	Nodes         []*cypher.Node
+	Relationships []*cypher.Relationship
+}
+
+// Run the query, collecting every node and relationship found in its rows, including those within lists and paths.
+// Labels, types, endpoints and properties are then looked up by id, so that relationships always
+// have both of their endpoints in the graph. Servers which report element ids (neo4j 5 and later)
+// are looked up by element id, and the nodes and relationships of the graph then have only element ids.
+func Collect(runner cypher.Runner, statement string, params map[string]interface{}) (*Graph, error) {
+	ids := &entityIDs{nodes: make(map[string]bool), rels: make(map[string]bool)}
+	result := runner.Run(statement, params)
+	for result.NextRow() {
+		row := result.GetRow()
+		for i := 0; i < row.Len(); i++ {
+			ids.collect(row.MetaAt(i))
+		}
+	}
+	if err := result.Err(); err != nil {
+		return nil, errors.WithMessage(err, "cypher/graphexport: failed to run query")
+	}
+	idOf := "id"
+	if ids.elementIDs {
+		idOf = "elementId"
+	}
+
+	g := new(Graph)
+	if len(ids.rels) > 0 {
+		rows, err := cypher.Collect(runner.Run(`MATCH ()-[r]->() WHERE `+idOf+`(r) IN $ids
+RETURN `+idOf+`(r) AS id, type(r) AS type, `+idOf+`(startNode(r)) AS start, `+idOf+`(endNode(r)) AS end,
+properties(r) AS properties`,
+			map[string]interface{}{"ids": ids.list(ids.rels)}))
+		if err != nil {
+			return nil, errors.WithMessage(err, "cypher/graphexport: failed to look up relationships")
+		}
+		for _, row := range rows {
+			rel := &cypher.Relationship{Properties: toMap(row.Get("properties"))}
+			if ids.elementIDs {
+				rel.ElementID, _ = row.Get("id").(string)
+				rel.StartElementID, _ = row.Get("start").(string)
+				rel.EndElementID, _ = row.Get("end").(string)
+			} else {
+				rel.ID = toInt64(row.Get("id"))
+				rel.StartID = toInt64(row.Get("start"))
+				rel.EndID = toInt64(row.Get("end"))
+			}
+			rel.Type, _ = row.Get("type").(string)
+			ids.nodes[startKey(rel)] = true
+			ids.nodes[endKey(rel)] = true
+			g.Relationships = append(g.Relationships, rel)
+		}
+	}
+	if len(ids.nodes) > 0 {
+		rows, err := cypher.Collect(runner.Run(`MATCH (n) WHERE `+idOf+`(n) IN $ids
+RETURN `+idOf+`(n) AS id, labels(n) AS labels, properties(n) AS properties`,
+			map[string]interface{}{"ids": ids.list(ids.nodes)}))
+		if err != nil {
+			return nil, errors.WithMessage(err, "cypher/graphexport: failed to look up nodes")
+		}
+		for _, row := range rows {
+			node := &cypher.Node{Properties: toMap(row.Get("properties"))}
+			if ids.elementIDs {
+				node.ElementID, _ = row.Get("id").(string)
+			} else {
+				node.ID = toInt64(row.Get("id"))
+			}
+			labels, _ := row.Get("labels").([]interface{})
+			for _, label := range labels {
+				if s, ok := label.(string); ok {
+					node.Labels = append(node.Labels, s)
+				}
+			}
+			g.Nodes = append(g.Nodes, node)
+		}
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return lessID(g.Nodes[i].ID, g.Nodes[i].ElementID, g.Nodes[j].ID, g.Nodes[j].ElementID)
+	})
+	sort.Slice(g.Relationships, func(i, j int) bool {
+		a, b := g.Relationships[i], g.Relationships[j]
+		return lessID(a.ID, a.ElementID, b.ID, b.ElementID)
+	})
+	return g, nil
+}
+
+// The ids of the nodes and relationships found in the rows of a result,
+// which are their element ids when the server reports them.
+type entityIDs struct {
+	elementIDs bool
+	nodes      map[string]bool
+	rels       map[string]bool
+}
+
+func (ids *entityIDs) collect(meta interface{}) {
+	switch m := meta.(type) {
+	case *cypher.Meta:
+		if m.Deleted {
+			return
+		}
+		key := strconv.FormatInt(m.ID, 10)
+		if m.ElementID != "" {
+			key = m.ElementID
+			ids.elementIDs = true
+		}
+		switch m.Type {
+		case "node":
+			ids.nodes[key] = true
+		case "relationship":
+			ids.rels[key] = true
+		}
+	case []interface{}:
+		for _, item := range m {
+			ids.collect(item)
+		}
+	}
+}
+
+// The ids as the parameter of a lookup: sorted element ids, or sorted numeric ids.
+func (ids *entityIDs) list(keys map[string]bool) interface{} {
+	if ids.elementIDs {
+		list := make([]string, 0, len(keys))
+		for key := range keys {
+			// Any numeric ids are from entities without element ids, which cannot be looked up by element id.
+			if _, err := strconv.ParseInt(key, 10, 64); err != nil {
+				list = append(list, key)
+			}
+		}
+		sort.Strings(list)
+		return list
+	}
+	list := make([]int64, 0, len(keys))
+	for key := range keys {
+		id, _ := strconv.ParseInt(key, 10, 64)
+		list = append(list, id)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
+
+func lessID(id int64, elementID string, otherID int64, otherElementID string) bool {
+	if id != otherID {
+		return id < otherID
+	}
+	return elementID < otherElementID
+}
+
+// The key of a node: its element id if it has one, or else its id.
+func nodeKey(n *cypher.Node) string {
+	if n.ElementID != "" {
+		return n.ElementID
+	}
+	return strconv.FormatInt(n.ID, 10)
+}
+
+func relKey(r *cypher.Relationship) string {
+	if r.ElementID != "" {
+		return r.ElementID
+	}
+	return strconv.FormatInt(r.ID, 10)
+}
+
+func startKey(r *cypher.Relationship) string {
+	if r.StartElementID != "" {
+		return r.StartElementID
+	}
+	return strconv.FormatInt(r.StartID, 10)
+}
+
+func endKey(r *cypher.Relationship) string {
+	if r.EndElementID != "" {
+		return r.EndElementID
+	}
+	return strconv.FormatInt(r.EndID, 10)
+}
+
+func toInt64(v interface{}) int64 {
+	id, _ := cypher.AsInt64(v)
+	return id
+}
+
+func toMap(v interface{}) map[string]interface{} {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m
+	}
+	return map[string]interface{}{}
+}
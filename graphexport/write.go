@@ -0,0 +1,260 @@
+package graphexport
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Format int
+
+const (
+	GraphML Format = iota
+	GEXF
+	DOT
+)
+
+// Parse the name of a format: graphml, gexf or dot.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "graphml":
+		return GraphML, nil
+	case "gexf":
+		return GEXF, nil
+	case "dot", "gv":
+		return DOT, nil
+	default:
+		return 0, errors.New("cypher/graphexport: unknown format: " + name)
+	}
+}
+
+// Write the graph in the given format.
+// Labels and types are written as attributes alongside the properties of each node and relationship.
+func Write(w io.Writer, g *Graph, format Format) error {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case GraphML:
+		writeGraphML(bw, g)
+	case GEXF:
+		writeGEXF(bw, g)
+	case DOT:
+		writeDOT(bw, g)
+	default:
+		return errors.Errorf("cypher/graphexport: unknown format: %v", format)
+	}
+	return errors.WithMessage(bw.Flush(), "cypher/graphexport: failed to write graph")
+}
+
+// An attribute declared for the properties of nodes or relationships.
+type attribute struct {
+	id    string
+	name  string
+	typ   string
+	index int
+}
+
+// Declare an attribute per property key, typed as the common type of all of its values.
+func attributes(props []map[string]interface{}, prefix string) []attribute {
+	types := make(map[string]string)
+	for _, p := range props {
+		for key, value := range p {
+			t := valueType(value)
+			if prev, ok := types[key]; ok && prev != t {
+				t = "string"
+			}
+			types[key] = t
+		}
+	}
+	keys := make([]string, 0, len(types))
+	for key := range types {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attrs := make([]attribute, len(keys))
+	for i, key := range keys {
+		attrs[i] = attribute{id: prefix + strconv.Itoa(i), name: key, typ: types[key], index: i}
+	}
+	return attrs
+}
+
+func valueType(value interface{}) string {
+	switch value.(type) {
+	case bool:
+		return "boolean"
+	case float64, float32:
+		return "double"
+	case int, int64:
+		return "long"
+	default:
+		return "string"
+	}
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func nodeProps(g *Graph) []map[string]interface{} {
+	props := make([]map[string]interface{}, len(g.Nodes))
+	for i, n := range g.Nodes {
+		props[i] = n.Properties
+	}
+	return props
+}
+
+func relProps(g *Graph) []map[string]interface{} {
+	props := make([]map[string]interface{}, len(g.Relationships))
+	for i, r := range g.Relationships {
+		props[i] = r.Properties
+	}
+	return props
+}
+
+func writeGraphML(w *bufio.Writer, g *Graph) {
+	nodeAttrs := attributes(nodeProps(g), "n")
+	relAttrs := attributes(relProps(g), "e")
+	w.WriteString(xml.Header)
+	w.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	w.WriteString(`  <key id="labels" for="node" attr.name="labels" attr.type="string"/>` + "\n")
+	w.WriteString(`  <key id="type" for="edge" attr.name="type" attr.type="string"/>` + "\n")
+	for _, a := range nodeAttrs {
+		fmt.Fprintf(w, "  <key id=\"%s\" for=\"node\" attr.name=\"%s\" attr.type=\"%s\"/>\n", a.id, escapeXML(a.name), a.typ)
+	}
+	for _, a := range relAttrs {
+		fmt.Fprintf(w, "  <key id=\"%s\" for=\"edge\" attr.name=\"%s\" attr.type=\"%s\"/>\n", a.id, escapeXML(a.name), a.typ)
+	}
+	w.WriteString(`  <graph id="G" edgedefault="directed">` + "\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(w, "    <node id=\"n%s\">\n", escapeXML(nodeKey(n)))
+		fmt.Fprintf(w, "      <data key=\"labels\">%s</data>\n", escapeXML(":"+strings.Join(n.Labels, ":")))
+		writeGraphMLData(w, nodeAttrs, n.Properties)
+		w.WriteString("    </node>\n")
+	}
+	for _, r := range g.Relationships {
+		fmt.Fprintf(w, "    <edge id=\"e%s\" source=\"n%s\" target=\"n%s\">\n",
+			escapeXML(relKey(r)), escapeXML(startKey(r)), escapeXML(endKey(r)))
+		fmt.Fprintf(w, "      <data key=\"type\">%s</data>\n", escapeXML(r.Type))
+		writeGraphMLData(w, relAttrs, r.Properties)
+		w.WriteString("    </edge>\n")
+	}
+	w.WriteString("  </graph>\n</graphml>\n")
+}
+
+func writeGraphMLData(w *bufio.Writer, attrs []attribute, props map[string]interface{}) {
+	for _, a := range attrs {
+		if v, ok := props[a.name]; ok && v != nil {
+			fmt.Fprintf(w, "      <data key=\"%s\">%s</data>\n", a.id, escapeXML(formatValue(v)))
+		}
+	}
+}
+
+func writeGEXF(w *bufio.Writer, g *Graph) {
+	nodeAttrs := attributes(nodeProps(g), "")
+	relAttrs := attributes(relProps(g), "")
+	w.WriteString(xml.Header)
+	w.WriteString(`<gexf xmlns="http://gexf.net/1.3" version="1.3">` + "\n")
+	w.WriteString(`  <graph defaultedgetype="directed">` + "\n")
+	writeGEXFAttributes(w, "node", "labels", nodeAttrs)
+	writeGEXFAttributes(w, "edge", "type", relAttrs)
+	w.WriteString("    <nodes>\n")
+	for _, n := range g.Nodes {
+		labels := strings.Join(n.Labels, ":")
+		fmt.Fprintf(w, "      <node id=\"%s\" label=\"%s\">\n", escapeXML(nodeKey(n)), escapeXML(labels))
+		writeGEXFValues(w, nodeAttrs, "labels", labels, n.Properties)
+		w.WriteString("      </node>\n")
+	}
+	w.WriteString("    </nodes>\n    <edges>\n")
+	for _, r := range g.Relationships {
+		fmt.Fprintf(w, "      <edge id=\"%s\" source=\"%s\" target=\"%s\" label=\"%s\">\n",
+			escapeXML(relKey(r)), escapeXML(startKey(r)), escapeXML(endKey(r)), escapeXML(r.Type))
+		writeGEXFValues(w, relAttrs, "type", r.Type, r.Properties)
+		w.WriteString("      </edge>\n")
+	}
+	w.WriteString("    </edges>\n  </graph>\n</gexf>\n")
+}
+
+// Declare the attributes of a class. The first attribute holds the labels or type.
+func writeGEXFAttributes(w *bufio.Writer, class, first string, attrs []attribute) {
+	fmt.Fprintf(w, "    <attributes class=\"%s\">\n", class)
+	fmt.Fprintf(w, "      <attribute id=\"%s\" title=\"%s\" type=\"string\"/>\n", first, first)
+	for _, a := range attrs {
+		fmt.Fprintf(w, "      <attribute id=\"%d\" title=\"%s\" type=\"%s\"/>\n", a.index, escapeXML(a.name), a.typ)
+	}
+	w.WriteString("    </attributes>\n")
+}
+
+func writeGEXFValues(w *bufio.Writer, attrs []attribute, firstID, firstValue string, props map[string]interface{}) {
+	w.WriteString("        <attvalues>\n")
+	fmt.Fprintf(w, "          <attvalue for=\"%s\" value=\"%s\"/>\n", firstID, escapeXML(firstValue))
+	for _, a := range attrs {
+		if v, ok := props[a.name]; ok && v != nil {
+			fmt.Fprintf(w, "          <attvalue for=\"%d\" value=\"%s\"/>\n", a.index, escapeXML(formatValue(v)))
+		}
+	}
+	w.WriteString("        </attvalues>\n")
+}
+
+func writeDOT(w *bufio.Writer, g *Graph) {
+	w.WriteString("digraph G {\n")
+	for _, n := range g.Nodes {
+		labels := strings.Join(n.Labels, ":")
+		fmt.Fprintf(w, "  %s [label=%s, labels=%s%s];\n",
+			dotID(nodeKey(n)), quoteDOT(":"+labels), quoteDOT(labels), dotAttributes(n.Properties))
+	}
+	for _, r := range g.Relationships {
+		fmt.Fprintf(w, "  %s -> %s [label=%s, type=%s%s];\n",
+			dotID(startKey(r)), dotID(endKey(r)), quoteDOT(r.Type), quoteDOT(r.Type), dotAttributes(r.Properties))
+	}
+	w.WriteString("}\n")
+}
+
+// Properties as additional attributes, prefixed to avoid clashing with graphviz attributes.
+func dotAttributes(props map[string]interface{}) string {
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, key := range keys {
+		if props[key] != nil {
+			b.WriteString(", " + quoteDOT("prop_"+key) + "=" + quoteDOT(formatValue(props[key])))
+		}
+	}
+	return b.String()
+}
+
+// The identifier of a node: n followed by its id, quoted when it is an element id.
+func dotID(key string) string {
+	if _, err := strconv.ParseInt(key, 10, 64); err == nil {
+		return "n" + key
+	}
+	return quoteDOT("n" + key)
+}
+
+func quoteDOT(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
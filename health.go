@@ -0,0 +1,51 @@
+package cypher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Information about the server which a DB is connected to.
+type ServerInfo struct {
+	Version  string `json:"version"`
+	Edition  string `json:"edition"`
+	Database string `json:"database"`
+	// The protocol used to talk to the server, such as http or https.
+	Protocol string `json:"protocol"`
+	// The endpoints advertised by the server, keyed by name.
+	Endpoints map[string]string `json:"endpoints"`
+}
+
+// Returns a handler for health checks, such as kubernetes readiness and liveness probes.
+// Each request pings the database, giving up after the timeout, or 5 seconds if it is 0.
+// Responds with 200 and the server info when the ping succeeds, and 503 with the error otherwise.
+func HealthHandler(db DB, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		body := struct {
+			Status string      `json:"status"`
+			Error  string      `json:"error,omitempty"`
+			Server *ServerInfo `json:"server,omitempty"`
+		}{Status: "ok"}
+		status := http.StatusOK
+		if err := db.Ping(ctx); err != nil {
+			status = http.StatusServiceUnavailable
+			body.Status, body.Error = "unavailable", err.Error()
+		} else {
+			info := db.ServerInfo()
+			body.Server = &info
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		if r.Method != http.MethodHead {
+			_ = json.NewEncoder(w).Encode(body)
+		}
+	})
+}
@@ -0,0 +1,433 @@
+// Package cyphertest is an in-memory stand-in for a cypher database, for the tests of the packages built on package cypher.
+// Statements are not executed: each is answered by a function of the test, and recorded for the test to inspect.
+package cyphertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"sync"
+)
+
+// A statement which was run, and the transaction it was run in, numbered from 1, or 0 if it was not.
+type Run struct {
+	Statement string
+	Params    map[string]interface{}
+	Options   cypher.RunOptions
+	TX        int
+}
+
+// DB is a cypher.DB whose statements are answered by Respond.
+type DB struct {
+	// Answers each statement. Statements are answered with an empty result when it is nil or returns nil.
+	Respond func(statement string, params map[string]interface{}) *Result
+	// The version reported by ServerInfo.
+	Version string
+
+	mu        sync.Mutex
+	runs      []Run
+	txs       int
+	open      int
+	commits   int
+	rollbacks int
+	closed    bool
+}
+
+func (db *DB) Run(statement string, params map[string]interface{}) cypher.Result {
+	return db.run(0, cypher.RunOptions{}, statement, params)
+}
+
+func (db *DB) RunWithOptions(opts cypher.RunOptions, statement string, params map[string]interface{}) cypher.Result {
+	return db.run(0, opts, statement, params)
+}
+
+func (db *DB) RunMany(cypherOrParams ...interface{}) cypher.Response {
+	return db.runMany(0, cypherOrParams)
+}
+
+func (db *DB) TX() (cypher.Transaction, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		return nil, errors.New("cyphertest: the database is closed")
+	}
+	db.txs++
+	db.open++
+	return &Transaction{db: db, id: db.txs}, nil
+}
+
+func (db *DB) TXJob(job func(tx cypher.Transaction) (interface{}, error)) (interface{}, error) {
+	tx, err := db.TX()
+	if err != nil {
+		return nil, err
+	}
+	val, err := job(tx)
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return nil, rbErr
+		}
+		return nil, err
+	}
+	return val, tx.Commit()
+}
+
+func (db *DB) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (db *DB) ServerInfo() cypher.ServerInfo {
+	return cypher.ServerInfo{Version: db.Version}
+}
+
+func (db *DB) OpenTransactions() int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.open
+}
+
+func (db *DB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.closed = true
+	return nil
+}
+
+// The statements run so far, in order.
+func (db *DB) Runs() []Run {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]Run(nil), db.runs...)
+}
+
+// The number of transactions committed and rolled back so far.
+func (db *DB) Ended() (commits, rollbacks int) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.commits, db.rollbacks
+}
+
+func (db *DB) Closed() bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.closed
+}
+
+func (db *DB) run(tx int, opts cypher.RunOptions, statement string, params map[string]interface{}) *Result {
+	db.mu.Lock()
+	db.runs = append(db.runs, Run{Statement: statement, Params: params, Options: opts, TX: tx})
+	db.mu.Unlock()
+	var result *Result
+	if db.Respond != nil {
+		result = db.Respond(statement, params)
+	}
+	if result == nil {
+		result = NewResult(nil)
+	}
+	return result
+}
+
+func (db *DB) runMany(tx int, cypherOrParams []interface{}) cypher.Response {
+	var statements []string
+	var params []map[string]interface{}
+	for _, val := range cypherOrParams {
+		switch v := val.(type) {
+		case string:
+			statements = append(statements, v)
+			params = append(params, nil)
+		case map[string]interface{}:
+			if len(params) > 0 {
+				params[len(params)-1] = v
+			}
+		}
+	}
+	res := &Response{results: make([]*Result, len(statements))}
+	for i, statement := range statements {
+		res.results[i] = db.run(tx, cypher.RunOptions{}, statement, params[i])
+		res.results[i].index = i
+	}
+	return res
+}
+
+// Transaction is a cypher.Transaction of a DB, which records its statements as run in it.
+type Transaction struct {
+	db    *DB
+	id    int
+	ended bool
+}
+
+func (tx *Transaction) Run(statement string, params map[string]interface{}) cypher.Result {
+	return tx.db.run(tx.id, cypher.RunOptions{}, statement, params)
+}
+
+func (tx *Transaction) RunWithOptions(opts cypher.RunOptions, statement string, params map[string]interface{}) cypher.Result {
+	return tx.db.run(tx.id, opts, statement, params)
+}
+
+func (tx *Transaction) RunMany(cypherOrParams ...interface{}) cypher.Response {
+	return tx.db.runMany(tx.id, cypherOrParams)
+}
+
+func (tx *Transaction) Commit() error {
+	return tx.end(&tx.db.commits)
+}
+
+func (tx *Transaction) Rollback() error {
+	return tx.end(&tx.db.rollbacks)
+}
+
+func (tx *Transaction) end(count *int) error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	if tx.ended {
+		return errors.New("cyphertest: the transaction has already ended")
+	}
+	tx.ended = true
+	tx.db.open--
+	*count++
+	return nil
+}
+
+// Driver connects to a new DB for each connection, whose statements are answered by Respond.
+type Driver struct {
+	Respond func(statement string, params map[string]interface{}) *Result
+
+	mu  sync.Mutex
+	dbs []*DB
+}
+
+func (d *Driver) Connect(uri, dbName, username, password string) (cypher.DB, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	db := &DB{Respond: d.Respond}
+	d.dbs = append(d.dbs, db)
+	return db, nil
+}
+
+// The databases connected to so far.
+func (d *Driver) DBs() []*DB {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*DB(nil), d.dbs...)
+}
+
+// Result is a cypher.Result of the given rows.
+type Result struct {
+	// The stats returned by Consume.
+	Stats Stats
+	// The error of the result, reported once its rows have been read.
+	Error error
+	// The number mode of its rows.
+	Numbers cypher.NumberMode
+
+	index    int
+	columns  []string
+	rows     [][]interface{}
+	next     int
+	consumed bool
+	graph    cypher.Graph
+}
+
+func NewResult(columns []string, rows ...[]interface{}) *Result {
+	return &Result{columns: columns, rows: rows}
+}
+
+func (r *Result) Index() int {
+	return r.index
+}
+
+func (r *Result) Columns() []string {
+	return r.columns
+}
+
+func (r *Result) NextRow() bool {
+	if r.consumed || r.next >= len(r.rows) {
+		r.consumed = true
+		return false
+	}
+	r.next++
+	return true
+}
+
+func (r *Result) GetRow() cypher.Row {
+	if r.next == 0 {
+		return nil
+	}
+	return &Row{keys: r.columns, values: r.rows[r.next-1], mode: r.Numbers}
+}
+
+func (r *Result) Err() error {
+	if r.next < len(r.rows) && !r.consumed {
+		return nil
+	}
+	return r.Error
+}
+
+func (r *Result) Consume() (cypher.Stats, error) {
+	r.consumed = true
+	if r.Error != nil {
+		return nil, r.Error
+	}
+	return r.Stats, nil
+}
+
+func (r *Result) Graph() *cypher.Graph {
+	return &r.graph
+}
+
+// Whether the result was consumed, or all of its rows were read.
+func (r *Result) Consumed() bool {
+	return r.consumed
+}
+
+// Response is a cypher.Response of the results of RunMany.
+type Response struct {
+	results []*Result
+	next    int
+}
+
+func (r *Response) NextResult() bool {
+	if r.next > 0 && r.results[r.next-1].Error != nil {
+		return false
+	}
+	if r.next >= len(r.results) {
+		return false
+	}
+	r.next++
+	return true
+}
+
+func (r *Response) GetResult() cypher.Result {
+	if r.next == 0 {
+		return nil
+	}
+	return r.results[r.next-1]
+}
+
+func (r *Response) Err() error {
+	if r.next > 0 {
+		return r.results[r.next-1].Error
+	}
+	return nil
+}
+
+func (r *Response) Consume() error {
+	for r.NextResult() {
+		if _, err := r.GetResult().Consume(); err != nil {
+			return err
+		}
+	}
+	return r.Err()
+}
+
+func (r *Response) Notifications() []cypher.Notification {
+	return nil
+}
+
+func (r *Response) LastBookmarks() []string {
+	return nil
+}
+
+// Row is a cypher.Row with its values in the order of its keys.
+type Row struct {
+	keys   []string
+	values []interface{}
+	mode   cypher.NumberMode
+}
+
+func NewRow(keys []string, values ...interface{}) *Row {
+	return &Row{keys: keys, values: values}
+}
+
+func (r *Row) GetAt(i int) interface{} {
+	return r.values[i]
+}
+
+func (r *Row) Get(n string) interface{} {
+	for i, key := range r.keys {
+		if key == n {
+			return r.values[i]
+		}
+	}
+	return nil
+}
+
+func (r *Row) Keys() []string {
+	return r.keys
+}
+
+func (r *Row) Values() []interface{} {
+	return r.values
+}
+
+func (r *Row) Len() int {
+	return len(r.values)
+}
+
+func (r *Row) AsMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(r.keys))
+	for i, key := range r.keys {
+		m[key] = r.values[i]
+	}
+	return m
+}
+
+func (r *Row) MetaAt(i int) interface{} {
+	return nil
+}
+
+func (r *Row) NumberMode() cypher.NumberMode {
+	return r.mode
+}
+
+func (r *Row) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range r.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		b, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+		buf.WriteByte(':')
+		if b, err = json.Marshal(r.values[i]); err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Stats are the counters of a result, keyed by their names in the http api, such as nodes_created.
+type Stats map[string]int
+
+func (s Stats) ConstraintsAdded() int     { return s["constraints_added"] }
+func (s Stats) ConstraintsRemoved() int   { return s["constraints_removed"] }
+func (s Stats) IndexesAdded() int         { return s["indexes_added"] }
+func (s Stats) IndexesRemoved() int       { return s["indexes_removed"] }
+func (s Stats) LabelsAdded() int          { return s["labels_added"] }
+func (s Stats) LabelsRemoved() int        { return s["labels_removed"] }
+func (s Stats) NodesCreated() int         { return s["nodes_created"] }
+func (s Stats) NodesDeleted() int         { return s["nodes_deleted"] }
+func (s Stats) PropertiesSet() int        { return s["properties_set"] }
+func (s Stats) RelationshipDeleted() int  { return s["relationship_deleted"] }
+func (s Stats) RelationshipsCreated() int { return s["relationships_created"] }
+func (s Stats) SystemUpdates() int        { return s["system_updates"] }
+
+func (s Stats) ContainsUpdates() bool {
+	for name, n := range s {
+		if name != "system_updates" && n > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Stats) ContainsSystemUpdates() bool {
+	return s.SystemUpdates() > 0
+}
@@ -0,0 +1,73 @@
+// Package httpapi holds what the drivers for the http interfaces of neo4j have in common:
+// sending authenticated requests, tracking open transactions, describing the server, logging,
+// and reading the values which the http interfaces represent as strings or without metadata.
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Authorizer supplies the Authorization header of each request, as neohttp.AuthProvider does.
+type Authorizer interface {
+	Authorization(ctx context.Context, refresh bool) (string, error)
+}
+
+// StaticAuth is a fixed Authorization header. Requests which it fails to authorize are not sent again,
+// as refreshing it would give the same credentials.
+type StaticAuth string
+
+func (a StaticAuth) Authorization(context.Context, bool) (string, error) {
+	return string(a), nil
+}
+
+// Client sends the requests of a driver.
+type Client struct {
+	// Supplies the credentials of each request. No auth is sent when nil.
+	Auth Authorizer
+	// Called when a request fails to reach the server.
+	OnUnreachable func()
+	Log           Logger
+}
+
+// Send a request with the Authorization header of the auth provider.
+// If the credentials are rejected, they are refreshed and the request is sent once more.
+func (c *Client) Do(ctx context.Context, method, url string, body []byte, header map[string]string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, errors.WithMessage(err, "could not create request")
+		}
+		req.Header.Set("Accept", "application/json")
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		if c.Auth != nil {
+			auth, err := c.Auth.Authorization(ctx, attempt > 0)
+			if err != nil {
+				return nil, errors.WithMessage(err, "could not authenticate")
+			}
+			if auth != "" {
+				req.Header.Set("Authorization", auth)
+			}
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			if c.OnUnreachable != nil {
+				c.OnUnreachable()
+			}
+			return nil, errors.WithMessage(err, "could not send request / receive response")
+		}
+		_, static := c.Auth.(StaticAuth)
+		if res.StatusCode != http.StatusUnauthorized || c.Auth == nil || static || attempt > 0 {
+			return res, nil
+		}
+		c.Log.Debugf("credentials were rejected - refreshing and retrying")
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+	}
+}
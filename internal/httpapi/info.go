@@ -0,0 +1,26 @@
+package httpapi
+
+import (
+	"github.com/tjbrockmeyer/cypher"
+	"net/url"
+)
+
+// Describe the server at the uri from its discovery document. Endpoints which are empty are left out.
+func ServerInfo(uri, database, version, edition string, endpoints map[string]string) cypher.ServerInfo {
+	info := cypher.ServerInfo{
+		Version:   version,
+		Edition:   edition,
+		Database:  database,
+		Protocol:  "http",
+		Endpoints: make(map[string]string),
+	}
+	if u, err := url.Parse(uri); err == nil && u.Scheme != "" {
+		info.Protocol = u.Scheme
+	}
+	for name, endpoint := range endpoints {
+		if endpoint != "" {
+			info.Endpoints[name] = endpoint
+		}
+	}
+	return info
+}
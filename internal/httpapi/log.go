@@ -0,0 +1,21 @@
+package httpapi
+
+import (
+	"github.com/tjbrockmeyer/cypher"
+	"log"
+)
+
+// Logger writes the log messages of a driver, prefixed with the name of its package.
+type Logger string
+
+// Write a message with a level prefix, such as "[WARN] ".
+func (l Logger) Printf(level, format string, args ...interface{}) {
+	log.Printf(level+string(l)+": "+format, args...)
+}
+
+// Write a message only when cypher.Debug is set.
+func (l Logger) Debugf(format string, args ...interface{}) {
+	if cypher.Debug {
+		l.Printf("[DEBUG] ", format, args...)
+	}
+}
@@ -0,0 +1,65 @@
+package httpapi
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"runtime/debug"
+	"sync"
+)
+
+// Transactions records the open transactions of a database until they are committed or rolled back,
+// so that those which are abandoned can be rolled back when the database is closed.
+type Transactions[T comparable] struct {
+	mu sync.Mutex
+	// Mapped to the stack trace of where they were opened when debugging.
+	open map[T][]byte
+}
+
+// Record a transaction as open until it is untracked.
+func (t *Transactions[T]) Track(tx T) T {
+	var stack []byte
+	if cypher.Debug {
+		stack = debug.Stack()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.open == nil {
+		t.open = make(map[T][]byte)
+	}
+	t.open[tx] = stack
+	return tx
+}
+
+func (t *Transactions[T]) Untrack(tx T) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.open, tx)
+}
+
+// The number of open transactions.
+func (t *Transactions[T]) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.open)
+}
+
+// Roll back every open transaction with the abandon function, returning the first error.
+func (t *Transactions[T]) Close(log Logger, abandon func(T) error) error {
+	t.mu.Lock()
+	abandoned := t.open
+	t.open = nil
+	t.mu.Unlock()
+	var err error
+	for tx, stack := range abandoned {
+		if stack != nil {
+			log.Debugf("rolling back an abandoned transaction, which was opened at:\n%s", stack)
+		}
+		if rbErr := abandon(tx); rbErr != nil && err == nil {
+			err = errors.WithMessage(rbErr, "failed to roll back an abandoned transaction")
+		}
+	}
+	if len(abandoned) > 0 {
+		log.Printf("[WARN] ", "rolled back %v abandoned transaction(s) on close", len(abandoned))
+	}
+	return err
+}
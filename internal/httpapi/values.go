@@ -0,0 +1,74 @@
+package httpapi
+
+import (
+	"github.com/tjbrockmeyer/cypher"
+	"strings"
+	"time"
+)
+
+// Get the metadata of the graph values within a value, for formats which have no separate metadata,
+// mirroring the structure of the value as described by cypher.Row.MetaAt.
+func MetaOf(value interface{}) interface{} {
+	switch v := value.(type) {
+	case cypher.Node:
+		return &cypher.Meta{ID: v.ID, ElementID: v.ElementID, Type: "node"}
+	case cypher.Relationship:
+		return &cypher.Meta{ID: v.ID, ElementID: v.ElementID, Type: "relationship"}
+	case cypher.Path:
+		list := make([]interface{}, 0, len(v.Nodes)+len(v.Relationships))
+		for i, node := range v.Nodes {
+			if i > 0 {
+				list = append(list, MetaOf(v.Relationships[i-1]))
+			}
+			list = append(list, MetaOf(node))
+		}
+		return list
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = MetaOf(item)
+		}
+		return list
+	default:
+		return nil
+	}
+}
+
+// Identify the type of a temporal value or duration from its string form, as written by neo4j:
+// date, time, localtime, datetime, localdatetime or duration.
+// The string must be known to be of a temporal value or duration, as any other string is given one of these types.
+func TemporalType(s string) string {
+	switch {
+	case strings.HasPrefix(s, "P") || strings.HasPrefix(s, "-P"):
+		return "duration"
+	case !strings.Contains(s, "T"):
+		if !strings.Contains(s, ":") {
+			return "date"
+		}
+		if strings.ContainsAny(s, "Z+-") {
+			return "time"
+		}
+		return "localtime"
+	case strings.ContainsAny(s[strings.IndexByte(s, 'T'):], "Z+-["):
+		return "datetime"
+	default:
+		return "localdatetime"
+	}
+}
+
+// Parse the string form of a datetime, with an offset and optionally the name of its zone in brackets,
+// such as 2024-01-02T03:04:05+01:00[Europe/Paris]. The time is in the named zone when it is known.
+func ParseDateTime(s string) (time.Time, error) {
+	zone := ""
+	if i := strings.IndexByte(s, '['); i >= 0 && strings.HasSuffix(s, "]") {
+		s, zone = s[:i], s[i+1:len(s)-1]
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil || zone == "" {
+		return t, err
+	}
+	if loc, err := time.LoadLocation(zone); err == nil {
+		t = t.In(loc)
+	}
+	return t, nil
+}
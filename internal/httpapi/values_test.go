@@ -0,0 +1,94 @@
+package httpapi
+
+import (
+	"github.com/tjbrockmeyer/cypher"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTemporalType(t *testing.T) {
+	tests := map[string]string{
+		"2024-01-02":                "date",
+		"+12024-01-02":              "date",
+		"03:04:05":                  "localtime",
+		"03:04:05.000000001":        "localtime",
+		"03:04:05Z":                 "time",
+		"03:04:05-05:00":            "time",
+		"2024-01-02T03:04:05":       "localdatetime",
+		"2024-01-02T03:04:05.5":     "localdatetime",
+		"2024-01-02T03:04:05Z":      "datetime",
+		"2024-01-02T03:04:05+01:00": "datetime",
+		"2024-01-02T03:04:05+01:00[Europe/Paris]": "datetime",
+		"2024-01-02T03:04:05[Europe/Paris]":       "datetime",
+		"P1Y2M3DT4H5M6.5S":                        "duration",
+		"PT0S":                                    "duration",
+		"-P1D":                                    "duration",
+	}
+	for s, want := range tests {
+		if got := TemporalType(s); got != want {
+			t.Errorf("TemporalType(%q) = %q, expected %q", s, got, want)
+		}
+	}
+}
+
+func TestParseDateTime(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("the zone database is not available:", err)
+	}
+	tests := []struct {
+		s    string
+		want time.Time
+		loc  *time.Location
+	}{
+		{"2024-01-02T03:04:05Z", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), time.UTC},
+		{"2024-01-02T03:04:05.000000001+01:00", time.Date(2024, 1, 2, 2, 4, 5, 1, time.UTC), nil},
+		{"2024-01-02T03:04:05+01:00[Europe/Paris]", time.Date(2024, 1, 2, 3, 4, 5, 0, paris), paris},
+		// Unknown zones keep the offset.
+		{"2024-01-02T03:04:05+01:00[Nowhere/Else]", time.Date(2024, 1, 2, 2, 4, 5, 0, time.UTC), nil},
+	}
+	for _, test := range tests {
+		got, err := ParseDateTime(test.s)
+		if err != nil {
+			t.Errorf("ParseDateTime(%q) returned the error %v", test.s, err)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("ParseDateTime(%q) = %v, expected %v", test.s, got, test.want)
+		}
+		if test.loc != nil && got.Location().String() != test.loc.String() {
+			t.Errorf("ParseDateTime(%q) is in %v, expected %v", test.s, got.Location(), test.loc)
+		}
+	}
+	for _, s := range []string{"2024-01-02T03:04:05", "2024-01-02", "[Europe/Paris]"} {
+		if _, err := ParseDateTime(s); err == nil {
+			t.Errorf("ParseDateTime(%q) did not fail", s)
+		}
+	}
+}
+
+func TestMetaOf(t *testing.T) {
+	a := cypher.Node{ID: 1, ElementID: "4:db:1"}
+	b := cypher.Node{ID: 2, ElementID: "4:db:2"}
+	r := cypher.Relationship{ID: 3, ElementID: "5:db:3"}
+	nodeMeta := func(n cypher.Node) *cypher.Meta { return &cypher.Meta{ID: n.ID, ElementID: n.ElementID, Type: "node"} }
+	relMeta := &cypher.Meta{ID: 3, ElementID: "5:db:3", Type: "relationship"}
+	tests := []struct {
+		value interface{}
+		want  interface{}
+	}{
+		{1.0, nil},
+		{map[string]interface{}{"a": a}, nil},
+		{a, nodeMeta(a)},
+		{r, relMeta},
+		{cypher.Path{Nodes: []cypher.Node{a, b}, Relationships: []cypher.Relationship{r}},
+			[]interface{}{nodeMeta(a), relMeta, nodeMeta(b)}},
+		{[]interface{}{a, "x", []interface{}{b}}, []interface{}{nodeMeta(a), nil, []interface{}{nodeMeta(b)}}},
+	}
+	for _, test := range tests {
+		if got := MetaOf(test.value); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("MetaOf(%#v) = %#v, expected %#v", test.value, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package cypher
+
+import (
+	"iter"
+)
+
+// Iterate over the rows of a result.
+// If reading a row fails, the error is yielded with a nil row as the final element.
+// If the loop is stopped early, the remainder of the result is consumed.
+//
+//	for row, err := range cypher.Rows(result) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func Rows(result Result) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		for result.NextRow() {
+			if !yield(result.GetRow(), nil) {
+				_, _ = result.Consume()
+				return
+			}
+		}
+		if err := result.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// Iterate over the rows of a result, unmarshaling each of them into a T as it is read.
+// Errors are yielded as with Rows, including errors from unmarshaling, after which iteration stops.
+func RowsAs[T any](result Result) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for row, err := range Rows(result) {
+			var value T
+			if err == nil {
+				err = UnmarshalRow(row, &value)
+				if err != nil {
+					_, _ = result.Consume()
+				}
+			}
+			if !yield(value, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Iterate over the results of a response.
+// If reading a result fails, the error is yielded with a nil result as the final element.
+// If the loop is stopped early, the remainder of the response is consumed.
+func Results(response Response) iter.Seq2[Result, error] {
+	return func(yield func(Result, error) bool) {
+		for response.NextResult() {
+			if !yield(response.GetResult(), nil) {
+				_ = response.Consume()
+				return
+			}
+		}
+		if err := response.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
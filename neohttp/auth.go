@@ -0,0 +1,104 @@
+package neohttp
+
+import (
+	"context"
+	"encoding/base64"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher/internal/httpapi"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuthProvider supplies the Authorization header of each request, including discovery.
+// When the server responds with 401 Unauthorized, the provider is asked to refresh its credentials
+// and the request is sent once more.
+type AuthProvider interface {
+	// Returns the value of the Authorization header, or an empty string to send none.
+	// When refresh is true, the previous value was rejected, and fresh credentials should be obtained if possible.
+	Authorization(ctx context.Context, refresh bool) (string, error)
+}
+
+// Authenticate with a username and password.
+func BasicAuth(username, password string) AuthProvider {
+	return httpapi.StaticAuth("Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+}
+
+// Authenticate with a fixed bearer token, such as one issued by an SSO provider.
+func BearerAuth(token string) AuthProvider {
+	return httpapi.StaticAuth("Bearer " + token)
+}
+
+// Authenticate with credentials of a custom scheme, sent as "<scheme> <credentials>".
+func CustomAuth(scheme, credentials string) AuthProvider {
+	return httpapi.StaticAuth(scheme + " " + credentials)
+}
+
+// Token is a bearer token which may expire.
+type Token struct {
+	Value string
+	// When the token expires. Never when zero.
+	Expiry time.Time
+}
+
+// Authenticate with bearer tokens obtained from a function, such as an SSO client.
+// The token is cached until shortly before it expires, or until the server rejects it.
+func TokenAuth(fetch func(ctx context.Context) (Token, error)) AuthProvider {
+	return &tokenAuth{fetch: fetch}
+}
+
+// Authenticate with a bearer token read from a file, such as a mounted secret which is rotated.
+// The file is read again when it has changed or when the server rejects the token.
+func TokenFileAuth(path string) AuthProvider {
+	var modTime time.Time
+	return &tokenAuth{
+		fetch: func(context.Context) (Token, error) {
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return Token{}, errors.WithMessage(err, "failed to read token file")
+			}
+			return Token{Value: strings.TrimSpace(string(b))}, nil
+		},
+		changed: func() bool {
+			info, err := os.Stat(path)
+			if err != nil {
+				return true
+			}
+			if !info.ModTime().Equal(modTime) {
+				modTime = info.ModTime()
+				return true
+			}
+			return false
+		},
+	}
+}
+
+// Refresh tokens this long before they expire.
+const tokenExpiryMargin = 10 * time.Second
+
+type tokenAuth struct {
+	fetch   func(ctx context.Context) (Token, error)
+	changed func() bool
+
+	mu    sync.Mutex
+	token Token
+	valid bool
+}
+
+func (a *tokenAuth) Authorization(ctx context.Context, refresh bool) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	expired := !a.token.Expiry.IsZero() && time.Now().Add(tokenExpiryMargin).After(a.token.Expiry)
+	changed := a.changed != nil && a.changed()
+	if refresh || !a.valid || expired || changed {
+		token, err := a.fetch(ctx)
+		if err != nil {
+			a.valid = false
+			return "", errors.WithMessage(err, "failed to fetch auth token")
+		}
+		a.token, a.valid = token, true
+	}
+	return "Bearer " + a.token.Value, nil
+}
@@ -2,111 +2,116 @@ package neohttp
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"github.com/pkg/errors"
 	"github.com/tjbrockmeyer/cypher"
+	"github.com/tjbrockmeyer/cypher/internal/httpapi"
+	"io"
 	"io/ioutil"
 	"net/http"
-	"time"
+	"strings"
+	"sync"
 )
 
 type database struct {
-	uri       string
-	basicAuth string
-	discovery struct {
-		BoltDirect string `json:"bolt_direct"`
-		Cluster    string `json:"cluster"`
-		TX         string `json:"transaction"`
-		Version    string `json:"neo4j_version"`
-		Edition    string `json:"neo4j_edition"`
-	}
+	uri    string
+	name   string
+	opts   Options
+	client httpapi.Client
+
+	discoveryMu sync.RWMutex
+	discovery   discovery
+	// Set when the discovery document must be fetched before the next request.
+	stale bool
+
+	txs httpapi.Transactions[*transaction]
 }
 
 func (db *database) Run(statement string, params map[string]interface{}) cypher.Result {
-	_, result := db.run("/commit", statement, params)
+	_, result := db.run(context.Background(), "/commit", statement, params, cypher.RunOptions{})
+	return result
+}
+
+func (db *database) RunWithOptions(opts cypher.RunOptions, statement string, params map[string]interface{}) cypher.Result {
+	_, result := db.run(context.Background(), "/commit", statement, params, opts)
 	return result
 }
 
 func (db *database) RunMany(cypherOrParams ...interface{}) cypher.Response {
-	return db.runMany("/commit", cypherOrParams...)
+	return db.runMany(context.Background(), "/commit", cypherOrParams...)
 }
 
 func (db *database) TX() (cypher.Transaction, error) {
-	return &transaction{
-		db:    db,
-		alive: true,
-	}, nil
+	return db.txs.Track(newTransaction(db)), nil
 }
 
 func (db *database) TXJob(job func(tx cypher.Transaction) (interface{}, error)) (interface{}, error) {
-	tx := &transaction{db: db, alive: true}
+	tx := db.txs.Track(newTransaction(db))
 	val, err := job(tx)
 	if err != nil {
-		err = tx.Rollback()
-		if err != nil {
-			return nil, errMsg(err, "error during rollback")
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return nil, errMsg(rbErr, "error during rollback")
 		}
 		return nil, errMsg(err, "error during TX job")
 	}
 	return val, errMsg(tx.Commit(), "error during commit")
 }
 
+func (db *database) OpenTransactions() int {
+	return db.txs.Len()
+}
+
 func (db *database) Close() error {
-	return nil
+	return db.txs.Close(logger, (*transaction).abandon)
 }
 
-func (db *database) connectWithRetry(retries int) error {
-	debugLog("connecting to the database via http(s) at (%s)", db.uri)
-	res, err := http.Get(db.uri)
-	if err != nil {
-		err = errMsg(err, "failed to get at uri ("+db.uri+")")
-	} else {
-		var body []byte
-		body, err = ioutil.ReadAll(res.Body)
-		if err != nil {
-			err = errMsg(err, "failed to read response body from uri ("+db.uri+")")
-		} else {
-			err = json.Unmarshal(body, &db.discovery)
-			if err != nil {
-				err = errMsg(err, "failed to unmarshal response body at uri ("+db.uri+")")
-			} else {
-				debugLog("successfully connected to the database at (%s)", db.uri)
-				return nil
-			}
-		}
+func (db *database) Ping(ctx context.Context) error {
+	res := db.getResponseContext(ctx, "POST", "/commit", request{Statements: []query{{Statement: "RETURN 1"}}})
+	if err := res.Consume(); err != nil {
+		return errMsg(err, "ping failed")
 	}
-
-	retries -= 1
-	if retries <= 0 {
-		debugLog("failed to connect to the database")
-		return errMsg(err, "failed to connect - no more retries")
+	if res.statusCode >= 400 {
+		return errors.Errorf("ping failed: server responded with status %v", res.statusCode)
 	}
-	debugLog("failed to connect - retries remaining: %v | retrying in 3 seconds...", retries)
-	<-time.After(time.Second * 3)
-	return db.connectWithRetry(retries)
+	return nil
+}
+
+func (db *database) ServerInfo() cypher.ServerInfo {
+	d := db.getDiscovery()
+	return httpapi.ServerInfo(db.uri, db.name, d.Version, d.Edition, map[string]string{
+		"bolt_direct":  d.BoltDirect,
+		"bolt_routing": d.BoltRouting,
+		"cluster":      d.Cluster,
+		"transaction":  d.TX,
+	})
 }
 
 func (db *database) getResponse(method, relPath string, body request) *response {
+	return db.getResponseContext(context.Background(), method, relPath, body)
+}
+
+func (db *database) getResponseContext(ctx context.Context, method, relPath string, body request) *response {
 	r := new(response)
-	b, err := json.Marshal(body)
+	d, err := db.ensureDiscovered(ctx)
 	if err != nil {
-		r.deferredErr = errors.WithMessage(err, "could not marshal request body")
+		r.deferredErr = err
 		return r
 	}
-	reqBody := bytes.NewReader(b)
-	req, err := http.NewRequest(method, db.discovery.TX+relPath, reqBody)
+	r.caps = d.caps
+	for i, q := range body.Statements {
+		if body.Statements[i].Parameters, err = cypher.ConvertParams(q.Parameters); err != nil {
+			r.deferredErr = errors.WithMessagef(err, "could not convert the parameters of statement %v", i)
+			return r
+		}
+	}
+	b, err := json.Marshal(body)
 	if err != nil {
-		r.deferredErr = errors.WithMessage(err, "could not create request")
+		r.deferredErr = errors.WithMessage(err, "could not marshal request body")
 		return r
 	}
-	req.Header.Set("Accept", "application/json;charset=UTF-8")
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Stream", "true")
-	if db.basicAuth != "" {
-		req.Header.Set("Authorization", db.basicAuth)
-	}
 	if cypher.Debug {
-		debugLog("requesting %s with payload %s", req.URL.String(), string(b))
+		debugLog("requesting %s with payload %s", d.TX+relPath, string(b))
 		for _, q := range body.Statements {
 			if q.Parameters != nil && len(q.Parameters) != 0 {
 				params, _ := json.Marshal(q.Parameters)
@@ -117,25 +122,53 @@ func (db *database) getResponse(method, relPath string, body request) *response
 			debugLog(q.Statement)
 		}
 	}
-	res, err := http.DefaultClient.Do(req)
+	accept := "application/json;charset=UTF-8"
+	if db.opts.Jolt && d.caps.jolt != "" {
+		accept = d.caps.jolt + ";strict=true, " + accept + ";q=0.9"
+	}
+	res, err := db.client.Do(ctx, method, d.TX+relPath, b, map[string]string{
+		"Accept":       accept,
+		"Content-Type": "application/json",
+		"X-Stream":     "true",
+	})
 	if err != nil {
-		r.deferredErr = errors.WithMessage(err, "could not send request / receive response")
+		r.deferredErr = err
 		return r
 	}
+	if res.StatusCode == http.StatusNotFound && relPath == "/commit" {
+		// The endpoints of the server have moved.
+		db.markStale()
+	}
 	r.statusCode = res.StatusCode
 	r.header = res.Header
-	r.dec = json.NewDecoder(res.Body)
-	r.resBody = res.Body
+	r.body = newStreamBody(res.Body)
+	r.resBody = r.body
+	contentType := res.Header.Get("Content-Type")
+	r.jolt = strings.HasPrefix(contentType, "application/vnd.neo4j.jolt")
+	var resBody io.Reader = r.body
 	if cypher.Debug {
-		bodyBytes, err := ioutil.ReadAll(res.Body)
+		bodyBytes, err := ioutil.ReadAll(r.body)
 		if err != nil {
 			r.deferredErr = errors.WithMessage(err, "DEBUG ERROR ioutil.ReadAll() failed to read body")
 			return r
 		}
 		debugLog("header: %+v\n response body: %v", res.Header, string(bodyBytes))
-		r.dec = json.NewDecoder(bytes.NewReader(bodyBytes))
+		resBody = bytes.NewReader(bodyBytes)
 	}
+	if strings.Contains(contentType, "json-seq") {
+		resBody = recordSeparatorFilter{resBody}
+	}
+	r.dec = json.NewDecoder(resBody)
 	debugLog("response received with status: %v", r.statusCode)
+	if r.jolt {
+		r.numbers = cypher.NumberInt64
+		r.dec.UseNumber()
+		return r
+	}
+	r.numbers = db.opts.Numbers
+	if r.numbers != cypher.NumberFloat64 {
+		r.dec.UseNumber()
+	}
 	err = r.parseKeys()
 	if err != nil {
 		r.deferredErr = err
@@ -143,15 +176,18 @@ func (db *database) getResponse(method, relPath string, body request) *response
 	return r
 }
 
-func (db *database) run(id, cypher string, params map[string]interface{}) (*response, cypher.Result) {
-	res := db.getResponse("POST", id, request{
-		Statements: []query{{
-			Statement:    cypher,
-			Parameters:   params,
-			IncludeStats: true,
-		}},
-	})
+func (db *database) run(ctx context.Context, id, statement string, params map[string]interface{}, opts cypher.RunOptions) (*response, cypher.Result) {
+	q := query{
+		Statement:    statement,
+		Parameters:   params,
+		IncludeStats: true,
+	}
+	if opts.Graph {
+		q.ResultDataContents = []string{"row", "graph"}
+	}
+	res := db.getResponseContext(ctx, "POST", id, request{Statements: []query{q}})
 	res.singleResult = true
+	res.graph = opts.Graph
 	if !res.NextResult() {
 		return res, &result{
 			res:         res,
@@ -161,7 +197,7 @@ func (db *database) run(id, cypher string, params map[string]interface{}) (*resp
 	return res, res.GetResult()
 }
 
-func (db *database) runMany(id string, cypherOrParams ...interface{}) cypher.Response {
+func (db *database) runMany(ctx context.Context, id string, cypherOrParams ...interface{}) cypher.Response {
 	statements := make([]query, 0, 10)
 	for _, val := range cypherOrParams {
 		switch v := val.(type) {
@@ -177,7 +213,7 @@ func (db *database) runMany(id string, cypherOrParams ...interface{}) cypher.Res
 				"RunMany() accepts only string cypher statements, or map[string]interface{} parameter declarations")}
 		}
 	}
-	return db.getResponse("POST", id, request{
+	return db.getResponseContext(ctx, "POST", id, request{
 		Statements: statements,
 	})
 }
@@ -0,0 +1,83 @@
+package neohttp
+
+import (
+	"context"
+	"github.com/tjbrockmeyer/cypher"
+	"testing"
+	"time"
+)
+
+func TestCloseRollsBackAbandonedTransactions(t *testing.T) {
+	s := newFakeServer(t, 5000)
+	db := s.connect(t)
+
+	// A transaction whose last result was never read.
+	unread, err := db.TX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := unread.Run("RETURN 1", nil)
+
+	// A transaction whose request is still waiting for a response.
+	waiting, err := db.TX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = waiting.Run("RETURN 1", nil).Consume(); err != nil {
+		t.Fatal(err)
+	}
+	hung := make(chan error)
+	go func() {
+		_, err := waiting.Run("HANG", nil).Consume()
+		hung <- err
+	}()
+	<-s.hung
+
+	within(t, "Close", func() {
+		if err := db.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	if err = <-hung; err == nil {
+		t.Error("a request cancelled by Close did not fail")
+	}
+	if _, err = cypher.Collect(result); err == nil {
+		t.Error("reading a result cancelled by Close did not fail")
+	}
+	if n := s.rollbacks(); n != 2 {
+		t.Errorf("rolled back %d transactions, expected 2", n)
+	}
+	if db.OpenTransactions() != 0 {
+		t.Errorf("%d transactions are still open", db.OpenTransactions())
+	}
+}
+
+func TestDiscoveryRetries(t *testing.T) {
+	s := newFakeServer(t, 1)
+	retry := RetryPolicy{Attempts: 3, InitialDelay: time.Millisecond}
+	tests := []struct {
+		name        string
+		opts        Options
+		unavailable int
+		ok          bool
+	}{
+		{"eager", Options{Retry: retry}, 2, true},
+		{"eager without enough attempts", Options{Retry: retry}, 3, false},
+		{"lazy", Options{Retry: retry, Lazy: true}, 2, true},
+		{"lazy without enough attempts", Options{Retry: retry, Lazy: true}, 3, false},
+		{"lazy without retries", Options{Lazy: true}, 1, false},
+	}
+	for _, test := range tests {
+		s.mu.Lock()
+		s.unavailable = test.unavailable
+		s.mu.Unlock()
+		db, err := NewDriver(test.opts).Connect(s.URL, "neo4j", "", "")
+		if err == nil {
+			err = db.Ping(context.Background())
+		}
+		if (err == nil) != test.ok {
+			t.Errorf("%s: connecting while the server is unavailable for %d requests returned %v",
+				test.name, test.unavailable, err)
+		}
+	}
+}
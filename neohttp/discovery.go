@@ -0,0 +1,134 @@
+package neohttp
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// The discovery document served at the root of the http api.
+type discovery struct {
+	BoltDirect  string `json:"bolt_direct"`
+	BoltRouting string `json:"bolt_routing"`
+	Cluster     string `json:"cluster"`
+	TX          string `json:"transaction"`
+	Version     string `json:"neo4j_version"`
+	Edition     string `json:"neo4j_edition"`
+
+	// Chosen from the version of the server.
+	caps capabilities
+}
+
+// RetryPolicy is the backoff between attempts to fetch the discovery document, when connecting,
+// or on first use when connecting lazily, or when discovering the endpoints again.
+type RetryPolicy struct {
+	// The maximum number of attempts. Values less than 1 mean a single attempt.
+	Attempts int
+	// The delay after the first failed attempt.
+	InitialDelay time.Duration
+	// The factor applied to the delay after each failed attempt. Values less than 1 keep the delay constant.
+	Multiplier float64
+	// The longest delay between attempts. No limit when 0.
+	MaxDelay time.Duration
+}
+
+// The delay before the given retry, counting from 1.
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := float64(p.InitialDelay)
+	for i := 1; i < retry && p.Multiplier > 1; i++ {
+		d *= p.Multiplier
+		if p.MaxDelay > 0 && d >= float64(p.MaxDelay) {
+			return p.MaxDelay
+		}
+	}
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		return p.MaxDelay
+	}
+	return time.Duration(d)
+}
+
+func (db *database) connectWithRetry(ctx context.Context) error {
+	policy := db.opts.Retry
+	for attempt := 1; ; attempt++ {
+		err := db.discover(ctx)
+		if err == nil {
+			return nil
+		}
+		if attempt >= policy.Attempts {
+			debugLog("failed to connect to the database")
+			return errMsg(err, "failed to connect - no more retries")
+		}
+		delay := policy.delay(attempt)
+		debugLog("failed to connect - retries remaining: %v | retrying in %v...", policy.Attempts-attempt, delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return errMsg(ctx.Err(), "failed to connect")
+		}
+	}
+}
+
+// Fetch the discovery document, replacing the current one.
+func (db *database) discover(ctx context.Context) error {
+	debugLog("connecting to the database via http(s) at (%s)", db.uri)
+	res, err := db.client.Do(ctx, "GET", db.uri, nil, map[string]string{"Accept": "application/json"})
+	if err != nil {
+		return errMsg(err, "failed to get at uri ("+db.uri+")")
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return errMsg(err, "failed to read response body from uri ("+db.uri+")")
+	}
+	var d discovery
+	if err = json.Unmarshal(body, &d); err != nil {
+		return errMsg(err, "failed to unmarshal response body at uri ("+db.uri+")")
+	}
+	caps, ok := capabilitiesOf(d.Version)
+	if !ok {
+		return errors.New("cypher/neohttp: found unsupported version of neo4j (" + d.Version +
+			") - supported versions are: {" + strings.Join(supportedMajorVersions(), ", ") + "}")
+	}
+	d.caps = caps
+	d.TX = strings.Replace(d.TX, "{databaseName}", db.name, 1)
+	db.discoveryMu.Lock()
+	db.discovery = d
+	db.stale = false
+	db.discoveryMu.Unlock()
+	debugLog("successfully connected to the database at (%s)", db.uri)
+	return nil
+}
+
+// Get the discovery document, fetching it first, with retries, if it has not been fetched or has gone stale.
+func (db *database) ensureDiscovered(ctx context.Context) (discovery, error) {
+	db.discoveryMu.RLock()
+	d, stale := db.discovery, db.stale
+	db.discoveryMu.RUnlock()
+	if !stale {
+		return d, nil
+	}
+	if err := db.connectWithRetry(ctx); err != nil {
+		return d, errMsg(err, "failed to discover the database endpoints")
+	}
+	return db.getDiscovery(), nil
+}
+
+func (db *database) getDiscovery() discovery {
+	db.discoveryMu.RLock()
+	defer db.discoveryMu.RUnlock()
+	return db.discovery
+}
+
+// Fetch the discovery document again before the next request,
+// as the server may have restarted or moved its endpoints.
+func (db *database) markStale() {
+	if !db.opts.Rediscover {
+		return
+	}
+	db.discoveryMu.Lock()
+	db.stale = true
+	db.discoveryMu.Unlock()
+}
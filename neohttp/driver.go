@@ -1,55 +1,82 @@
 // Package neohttp implements a driver for neo4j via the http(s) interface.
 // Use with package cypher by importing this package as _ and connecting using cypher.Connect("neohttp", ...)
+//
+// The driver registered as "neohttp" uses DefaultOptions.
+// Drivers with other options may be registered using cypher.Register(name, neohttp.NewDriver(opts)).
 package neohttp
 
 import (
-	"encoding/base64"
-	"fmt"
-	"github.com/pkg/errors"
+	"context"
 	"github.com/tjbrockmeyer/cypher"
-	"strings"
+	"github.com/tjbrockmeyer/cypher/internal/httpapi"
+	"time"
 )
 
-var supportedMajorVersions = []string{
-	"4",
+func init() {
+	cypher.Register("neohttp", NewDriver(DefaultOptions()))
 }
 
-func init() {
-	cypher.Register("neohttp", driver{})
+type Options struct {
+	// The backoff between attempts to reach the server when connecting.
+	Retry RetryPolicy
+	// Return from Connect immediately, reaching the server when the database is first used.
+	// Errors reaching the server are then reported by the first request instead of Connect,
+	// once the attempts of the Retry policy have failed.
+	Lazy bool
+	// Fetch the discovery document again after a request fails to reach the server,
+	// or the transaction endpoint is not found, in case the server restarted or moved its endpoints.
+	// The next request fetches it, retrying as when connecting.
+	Rediscover bool
+	// Supplies the credentials of each request. When nil, basic auth is used with the username and password
+	// given to Connect, or no auth if the username is empty.
+	Auth AuthProvider
+	// Ask for results in the jolt format, in which values are tagged with their types.
+	// Integers then keep their precision, and temporal, spatial and graph values are returned as the types of
+	// package cypher instead of plain json. Servers which do not support jolt respond with plain json as usual.
+	Jolt bool
+	// How the numbers of rows are represented. With cypher.NumberFloat64, the default, integers beyond 2^53
+	// lose precision. With cypher.NumberInt64, the server must send floats with a fraction or exponent, as neo4j does.
+	// Rows in the jolt format always use cypher.NumberInt64, as their values are tagged with their types.
+	Numbers cypher.NumberMode
 }
 
-type driver struct{}
+// Retries connecting 3 times, 3 seconds apart, and rediscovers the server's endpoints after failures.
+func DefaultOptions() Options {
+	return Options{
+		Retry: RetryPolicy{
+			Attempts:     4,
+			InitialDelay: 3 * time.Second,
+		},
+		Rediscover: true,
+	}
+}
+
+type driver struct {
+	opts Options
+}
+
+// Create a driver which connects with the given options.
+func NewDriver(opts Options) cypher.Driver {
+	return driver{opts: opts}
+}
 
 func (d driver) Connect(uri, dbName, username, password string) (cypher.DB, error) {
 	db := &database{
-		uri:       uri,
-		discovery: struct {
-			BoltDirect string `json:"bolt_direct"`
-			Cluster    string `json:"cluster"`
-			TX         string `json:"transaction"`
-			Version    string `json:"neo4j_version"`
-			Edition    string `json:"neo4j_edition"`
-		}{},
+		uri:   uri,
+		name:  dbName,
+		opts:  d.opts,
+		stale: true,
 	}
-	if username != "" {
-		db.basicAuth = "Basic " + base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(username, ":", password)))
+	auth := d.opts.Auth
+	if auth == nil && username != "" {
+		auth = BasicAuth(username, password)
 	}
-	if err := db.connectWithRetry(4); err != nil {
-		return nil, err
+	db.client = httpapi.Client{Auth: auth, OnUnreachable: db.markStale, Log: logger}
+	if d.opts.Lazy {
+		return db, nil
 	}
-	if !versionIsSupported(strings.Split(db.discovery.Version, ".")[0]) {
-		return nil, errors.New("cypher/neohttp: found unsupported version of neo4j - supported versions are: {" +
-			strings.Join(supportedMajorVersions, ", ") + "}")
+	if err := db.connectWithRetry(context.Background()); err != nil {
+		return nil, err
 	}
-	db.discovery.TX = strings.Replace(db.discovery.TX, "{databaseName}", dbName, 1)
 	return db, nil
 }
-
-func versionIsSupported(majorVersion string) bool {
-	for _, mv := range supportedMajorVersions {
-		if mv == majorVersion {
-			return true
-		}
-	}
-	return false
-}
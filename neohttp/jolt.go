@@ -0,0 +1,425 @@
+package neohttp
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"github.com/tjbrockmeyer/cypher/internal/httpapi"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A jolt response is a sequence of events, each of which is an object with a single key naming the kind of event.
+// Each result is a header event, a data event per row and a summary event.
+// Info and error events, holding the remaining keys of a json response, follow the results.
+type joltEvent struct {
+	kind string
+	body json.RawMessage
+}
+
+type joltInfo struct {
+	Commit      string `json:"commit"`
+	Transaction *struct {
+		Expires string `json:"expires"`
+	} `json:"transaction"`
+	Notifications []cypher.Notification `json:"notifications"`
+	LastBookmarks []string              `json:"lastBookmarks"`
+}
+
+// Read the next event, returning nil at the end of the response.
+func (r *response) nextEvent() (*joltEvent, error) {
+	if e := r.pendingEvent; e != nil {
+		r.pendingEvent = nil
+		return e, nil
+	}
+	var m map[string]json.RawMessage
+	if err := r.dec.Decode(&m); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(m) != 1 {
+		return nil, errors.Errorf("jolt event has %v keys instead of 1", len(m))
+	}
+	for kind, body := range m {
+		return &joltEvent{kind: kind, body: body}, nil
+	}
+	return nil, nil
+}
+
+// Read events up to the header of the next result.
+// If there are no more results, the remaining events will be read and processed.
+func (r *response) nextJoltResult() error {
+	for {
+		e, err := r.nextEvent()
+		if err != nil {
+			return err
+		}
+		if e == nil {
+			r.lastResult = nil
+			r.consumed = true
+			return nil
+		}
+		switch e.kind {
+		case "header":
+			var header struct {
+				Fields []string `json:"fields"`
+			}
+			if err = json.Unmarshal(e.body, &header); err != nil {
+				return errors.WithMessage(err, "failed to read result header")
+			}
+			res := &result{
+				res:           r,
+				index:         r.resultCount,
+				parseStarted:  true,
+				readingRows:   true,
+				Columns_:      header.Fields,
+				columnMapping: make(map[string]int, len(header.Fields)),
+			}
+			for index, column := range header.Fields {
+				res.columnMapping[column] = index
+			}
+			r.lastResult = res
+			r.resultCount++
+			return nil
+		case "info":
+			var info joltInfo
+			if err = json.Unmarshal(e.body, &info); err != nil {
+				return errors.WithMessage(err, "failed to read info event")
+			}
+			r.commit, r.transaction, r.notifications = info.Commit, info.Transaction, info.Notifications
+			if r.caps.bookmarks {
+				r.lastBookmarks = info.LastBookmarks
+			}
+		case "error":
+			var body struct {
+				Errors json.RawMessage `json:"errors"`
+			}
+			if err = json.Unmarshal(e.body, &body); err == nil && body.Errors != nil {
+				err = json.Unmarshal(body.Errors, &r.errors)
+			}
+			if err != nil {
+				return errors.WithMessage(err, "failed to read error event")
+			}
+		default:
+			// A data or summary event of a result which ended early, or an event this driver does not know about.
+			debugLog("skipping jolt event %q", e.kind)
+		}
+	}
+}
+
+// Read the next row of the result.
+// If the result has ended, the summary is read into 'Stats' and nil is returned for the next row.
+func (r *result) nextJoltRow() error {
+	r.lastRow = nil
+	e, err := r.res.nextEvent()
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		r.consumed = true
+		return nil
+	}
+	switch e.kind {
+	case "data":
+		var raw []interface{}
+		if err = decodeNumbers(e.body, &raw); err != nil {
+			return errors.WithMessage(err, "failed to read row")
+		}
+		values := make([]interface{}, len(raw))
+		for i, v := range raw {
+			if values[i], err = fromJolt(v); err != nil {
+				return errors.WithMessagef(err, "failed to decode the value of column %v", i)
+			}
+		}
+		rw := &row{keys: r.Columns_, columns: r.columnMapping, Row: values, numbers: cypher.NumberInt64}
+		r.addToGraph(rw)
+		r.lastRow = rw
+		return nil
+	case "summary":
+		var summary struct {
+			Stats *stats `json:"stats"`
+		}
+		if err = json.Unmarshal(e.body, &summary); err != nil {
+			return errors.WithMessage(err, "failed to read result summary")
+		}
+		if summary.Stats != nil {
+			r.Stats = *summary.Stats
+		}
+		r.consumed = true
+		return nil
+	default:
+		// The result ended without a summary, such as when the statement failed.
+		r.res.pendingEvent = e
+		r.consumed = true
+		return nil
+	}
+}
+
+func decodeNumbers(b []byte, v interface{}) error {
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// Convert a jolt value into its Go representation. Values tagged with a sigil are converted as follows:
+//
+//	Z (integer): int64
+//	R (float): float64
+//	U (string): string
+//	? (boolean): bool
+//	# (bytes): []byte
+//	[] (list): []interface{}
+//	{} (map): map[string]interface{}
+//	T (temporal): time.Time for dates and date times, in UTC unless the value has a zone or offset,
+//	    and string for times and durations
+//	@ (point): cypher.Point
+//	() (node): cypher.Node
+//	-> and <- (relationship): cypher.Relationship
+//	.. (path): cypher.Path
+//
+// Untagged numbers are integers when they have no fraction, and untagged lists and maps are converted item by item.
+func fromJolt(v interface{}) (interface{}, error) {
+	switch x := v.(type) {
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return i, nil
+		}
+		return x.Float64()
+	case []interface{}:
+		return joltList(x)
+	case map[string]interface{}:
+		if len(x) == 1 {
+			for sigil, value := range x {
+				if typed, ok, err := fromSigil(sigil, value); ok {
+					return typed, errors.WithMessage(err, "failed to decode jolt value tagged "+sigil)
+				}
+			}
+		}
+		return joltMap(x)
+	default:
+		return v, nil
+	}
+}
+
+// Convert a value tagged with a sigil. Returns false if the sigil is not known.
+func fromSigil(sigil string, value interface{}) (interface{}, bool, error) {
+	switch sigil {
+	case "Z":
+		i, err := strconv.ParseInt(joltString(value), 10, 64)
+		return i, true, err
+	case "R":
+		f, err := strconv.ParseFloat(joltString(value), 64)
+		return f, true, err
+	case "U":
+		return joltString(value), true, nil
+	case "?":
+		b, err := strconv.ParseBool(joltString(value))
+		return b, true, err
+	case "#":
+		b, err := hex.DecodeString(strings.ReplaceAll(joltString(value), " ", ""))
+		return b, true, err
+	case "[]":
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, true, errors.New("list is not an array")
+		}
+		list, err := joltList(items)
+		return list, true, err
+	case "{}":
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, true, errors.New("map is not an object")
+		}
+		m, err := joltMap(entries)
+		return m, true, err
+	case "T":
+		t, err := parseTemporal(joltString(value))
+		return t, true, err
+	case "@":
+		p, err := cypher.ParsePoint(joltString(value))
+		return p, true, err
+	case "()":
+		n, err := joltNode(value)
+		return n, true, err
+	case "->", "<-":
+		rel, err := joltRelationship(value, sigil == "<-")
+		return rel, true, err
+	case "..":
+		p, err := joltPath(value)
+		return p, true, err
+	default:
+		return nil, false, nil
+	}
+}
+
+func joltString(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case json.Number:
+		return x.String()
+	case bool:
+		return strconv.FormatBool(x)
+	default:
+		return ""
+	}
+}
+
+func joltList(items []interface{}) ([]interface{}, error) {
+	list := make([]interface{}, len(items))
+	for i, item := range items {
+		v, err := fromJolt(item)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to decode list item %v", i)
+		}
+		list[i] = v
+	}
+	return list, nil
+}
+
+func joltMap(entries map[string]interface{}) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, len(entries))
+	for key, entry := range entries {
+		v, err := fromJolt(entry)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to decode map entry "+key)
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+// Read an identity, which is a numeric id in jolt version 1 and an element id in version 2.
+func joltIdentity(v interface{}) (int64, string, error) {
+	id, err := fromJolt(v)
+	if err != nil {
+		return 0, "", err
+	}
+	switch x := id.(type) {
+	case int64:
+		return x, "", nil
+	case string:
+		return 0, x, nil
+	default:
+		return 0, "", errors.Errorf("invalid identity: %v", v)
+	}
+}
+
+// A node is [id, [labels...], {properties...}].
+func joltNode(v interface{}) (cypher.Node, error) {
+	var n cypher.Node
+	parts, ok := v.([]interface{})
+	if !ok || len(parts) != 3 {
+		return n, errors.New("node is not an array of 3 elements")
+	}
+	var err error
+	if n.ID, n.ElementID, err = joltIdentity(parts[0]); err != nil {
+		return n, err
+	}
+	labels, err := fromJolt(parts[1])
+	if err != nil {
+		return n, err
+	}
+	list, _ := labels.([]interface{})
+	n.Labels = make([]string, len(list))
+	for i, label := range list {
+		n.Labels[i], _ = label.(string)
+	}
+	n.Properties, err = joltProperties(parts[2])
+	return n, err
+}
+
+// A relationship is [id, start id, type, end id, {properties...}] when pointing right,
+// and [id, end id, type, start id, {properties...}] when pointing left.
+func joltRelationship(v interface{}, left bool) (cypher.Relationship, error) {
+	var rel cypher.Relationship
+	parts, ok := v.([]interface{})
+	if !ok || len(parts) != 5 {
+		return rel, errors.New("relationship is not an array of 5 elements")
+	}
+	start, end := parts[1], parts[3]
+	if left {
+		start, end = end, start
+	}
+	var err error
+	if rel.ID, rel.ElementID, err = joltIdentity(parts[0]); err != nil {
+		return rel, err
+	}
+	if rel.StartID, rel.StartElementID, err = joltIdentity(start); err != nil {
+		return rel, err
+	}
+	if rel.EndID, rel.EndElementID, err = joltIdentity(end); err != nil {
+		return rel, err
+	}
+	rel.Type = joltString(parts[2])
+	rel.Properties, err = joltProperties(parts[4])
+	return rel, err
+}
+
+func joltProperties(v interface{}) (map[string]interface{}, error) {
+	props, err := fromJolt(v)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := props.(map[string]interface{})
+	return m, nil
+}
+
+// A path is a list alternating between nodes and relationships.
+func joltPath(v interface{}) (cypher.Path, error) {
+	var p cypher.Path
+	items, ok := v.([]interface{})
+	if !ok {
+		return p, errors.New("path is not an array")
+	}
+	for i, item := range items {
+		e, err := fromJolt(item)
+		if err != nil {
+			return p, errors.WithMessagef(err, "failed to decode path element %v", i)
+		}
+		switch x := e.(type) {
+		case cypher.Node:
+			p.Nodes = append(p.Nodes, x)
+		case cypher.Relationship:
+			p.Relationships = append(p.Relationships, x)
+		default:
+			return p, errors.Errorf("path element %v is neither a node nor a relationship", i)
+		}
+	}
+	return p, nil
+}
+
+// Parse a temporal value, telling apart its type by its format.
+func parseTemporal(s string) (interface{}, error) {
+	switch httpapi.TemporalType(s) {
+	case "date":
+		return time.Parse("2006-01-02", s)
+	case "datetime":
+		return httpapi.ParseDateTime(s)
+	case "localdatetime":
+		return time.Parse("2006-01-02T15:04:05.999999999", s)
+	default:
+		// A duration, time or local time.
+		return s, nil
+	}
+}
+
+// Drops the record separators of a json text sequence (RFC 7464), leaving whitespace-separated json values.
+type recordSeparatorFilter struct {
+	r io.Reader
+}
+
+func (f recordSeparatorFilter) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	for i := 0; i < n; i++ {
+		if p[i] == 0x1e {
+			p[i] = '\n'
+		}
+	}
+	return n, err
+}
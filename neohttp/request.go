@@ -8,5 +8,7 @@ type query struct {
 	Statement  string                 `json:"statement"`
 	Parameters map[string]interface{} `json:"parameters,omitempty"`
 	IncludeStats bool `json:"includeStats,omitempty"`
+	// The formats of each row, such as "row" and "graph". Defaults to "row" when empty.
+	ResultDataContents []string `json:"resultDataContents,omitempty"`
 }
 
@@ -1,6 +1,7 @@
 package neohttp
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/pkg/errors"
@@ -8,21 +9,36 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 )
 
 type response struct {
 	deferredErr error
 	dec         *json.Decoder
 	resBody     io.Closer
+	// The body of the response, which a later request on the same transaction may drain or cancel.
+	body *streamBody
 
 	parseStarted   bool
 	readingResults bool
 	consumed       bool
 	singleResult   bool
+	// The body is a sequence of jolt events rather than a single json object.
+	jolt bool
+	// How the numbers of rows are represented.
+	numbers cypher.NumberMode
+	// RunOptions.Graph was requested, so the nodes and relationships of rows are collected.
+	graph bool
+	// An event which was read but belongs to the caller of the next read.
+	pendingEvent *joltEvent
 
 	resultCount int
 	lastResult  *result
 
+	finished bool
+	onDone   func()
+
+	caps       capabilities
 	statusCode int
 	header     http.Header
 	errors     []struct {
@@ -33,6 +49,8 @@ type response struct {
 	transaction *struct {
 		Expires string `json:"expires"`
 	}
+	notifications []cypher.Notification
+	lastBookmarks []string
 }
 
 func (r *response) NextResult() bool {
@@ -42,6 +60,7 @@ func (r *response) NextResult() bool {
 	err := r.nextResult()
 	if err != nil {
 		r.deferredErr = errMsg(err, "failed to get the next result")
+		r.finish()
 		return false
 	}
 	if r.lastResult == nil {
@@ -59,7 +78,16 @@ func (r *response) Err() error {
 	return r.deferredErr
 }
 
+func (r *response) Notifications() []cypher.Notification {
+	return r.notifications
+}
+
+func (r *response) LastBookmarks() []string {
+	return r.lastBookmarks
+}
+
 func (r *response) Consume() error {
+	defer r.finish()
 	if r.deferredErr != nil {
 		return r.deferredErr
 	}
@@ -75,7 +103,7 @@ func (r *response) Consume() error {
 				debugLog("response errors found: %v", err)
 				return errMsg(err, "database returned errors")
 			}
-			return errMsg(r.resBody.Close(), "failed to close the response body")
+			return nil
 		}
 		if _, err = r.lastResult.Consume(); err != nil {
 			return err
@@ -83,6 +111,32 @@ func (r *response) Consume() error {
 	}
 }
 
+// Close the response body and notify the owner of the response that it is done.
+// Safe to call more than once.
+func (r *response) finish() {
+	if r.finished {
+		return
+	}
+	r.finished = true
+	if r.resBody != nil {
+		if err := r.resBody.Close(); err != nil {
+			debugLog("failed to close the response body: %v", err)
+		}
+	}
+	if r.onDone != nil {
+		r.onDone()
+	}
+}
+
+// Call f once the response is done, immediately if it is already done.
+func (r *response) whenDone(f func()) {
+	if r.finished {
+		f()
+		return
+	}
+	r.onDone = f
+}
+
 func (r *response) parseKeys() error {
 	if !r.parseStarted {
 		_, err := r.dec.Token()
@@ -110,8 +164,22 @@ func (r *response) parseKeys() error {
 			err = r.dec.Decode(&r.commit)
 		case "transaction":
 			err = r.dec.Decode(&r.transaction)
+		case "notifications":
+			err = r.dec.Decode(&r.notifications)
+		case "lastBookmarks":
+			if r.caps.bookmarks {
+				err = r.dec.Decode(&r.lastBookmarks)
+			} else {
+				err = skipValue(r.dec)
+			}
 		default:
-			return errors.New("invalid token found: " + fmt.Sprint(t))
+			key, ok := t.(string)
+			if !ok {
+				return errors.New("invalid token found: " + fmt.Sprint(t))
+			}
+			// Newer servers may add keys which this driver does not know about.
+			debugLog("skipping unknown response key %q", key)
+			err = skipValue(r.dec)
 		}
 		if err != nil {
 			return errors.WithMessage(err, "failed to read key "+t.(string))
@@ -139,6 +207,9 @@ func (r *response) nextResult() error {
 			return err
 		}
 	}
+	if r.jolt {
+		return r.nextJoltResult()
+	}
 	if !r.dec.More() {
 		r.lastResult = nil
 		_, err := r.dec.Token()
@@ -172,3 +243,91 @@ func (r *response) getErrors() error {
 	debugLog("no response errors found")
 	return nil
 }
+
+// Read and discard the next value of the decoder.
+func skipValue(dec *json.Decoder) error {
+	var discard json.RawMessage
+	return dec.Decode(&discard)
+}
+
+// The body of a response as it is streamed from the server.
+// A transaction sends its next request only once the previous response has been received,
+// so the next request drains the rest of the body into memory for its reader to continue from,
+// or cancels it, failing any further reads.
+type streamBody struct {
+	mu   sync.Mutex
+	body io.ReadCloser
+	// Read instead of the body once it has been drained or cancelled.
+	rest   io.Reader
+	closed bool
+}
+
+func newStreamBody(body io.ReadCloser) *streamBody {
+	return &streamBody{body: body}
+}
+
+func (b *streamBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rest != nil {
+		return b.rest.Read(p)
+	}
+	return b.body.Read(p)
+}
+
+func (b *streamBody) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	return b.body.Close()
+}
+
+// Read the rest of the body into memory and close it.
+func (b *streamBody) drain() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	data, err := io.ReadAll(b.body)
+	if err != nil {
+		b.rest = io.MultiReader(bytes.NewReader(data), errReader{err})
+	} else {
+		b.rest = bytes.NewReader(data)
+	}
+	if err = b.body.Close(); err != nil {
+		debugLog("failed to close the response body: %v", err)
+	}
+}
+
+// Close the body, so that further reads fail with the given error.
+func (b *streamBody) cancel(reason error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	b.rest = errReader{reason}
+	if err := b.body.Close(); err != nil {
+		debugLog("failed to close the response body: %v", err)
+	}
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
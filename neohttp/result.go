@@ -15,15 +15,20 @@ type result struct {
 	readingRows  bool
 	consumed     bool
 	lastRow      cypher.Row
+	graph        *cypher.Graph
 
-	Columns []string `json:"columns"`
-	Stats   stats    `json:"stats"`
+	Columns_ []string `json:"columns"`
+	Stats    stats    `json:"stats"`
 }
 
 func (r *result) Index() int {
 	return r.index
 }
 
+func (r *result) Columns() []string {
+	return r.Columns_
+}
+
 func (r *result) NextRow() bool {
 	if r.deferredErr != nil || r.consumed {
 		return r.nextRowDone()
@@ -31,6 +36,7 @@ func (r *result) NextRow() bool {
 	err := r.nextRow()
 	if err != nil {
 		r.deferredErr = errMsg(err, "failed to get the next row")
+		r.res.finish()
 		return r.nextRowDone()
 	}
 	if r.lastRow == nil {
@@ -47,13 +53,44 @@ func (r *result) Err() error {
 	return r.deferredErr
 }
 
+func (r *result) Graph() *cypher.Graph {
+	if r.graph == nil {
+		r.graph = new(cypher.Graph)
+	}
+	return r.graph
+}
+
+// Add the nodes and relationships of a row to the graph if it was requested, from the graph format,
+// or from its values when they are graph values, as with jolt.
+func (r *result) addToGraph(rw *row) {
+	if !r.res.graph {
+		return
+	}
+	if rw.Graph != nil {
+		rw.Graph.addTo(r.Graph())
+		return
+	}
+	for _, value := range rw.Row {
+		r.Graph().AddValue(value)
+	}
+}
+
 func (r *result) Consume() (cypher.Stats, error) {
+	if r.deferredErr != nil {
+		r.res.finish()
+		return nil, r.deferredErr
+	}
 	for {
 		err := r.nextRow()
 		if err != nil {
 			return nil, errMsg(err, "failed to get the next row")
 		}
 		if r.lastRow == nil {
+			if r.res.singleResult {
+				if err = r.res.Consume(); err != nil {
+					return nil, err
+				}
+			}
 			return &r.Stats, nil
 		}
 	}
@@ -77,10 +114,10 @@ func (r *result) parseKeys() error {
 		}
 		switch t {
 		case "columns":
-			err = r.res.dec.Decode(&r.Columns)
+			err = r.res.dec.Decode(&r.Columns_)
 			if err == nil {
-				r.columnMapping = make(map[string]int, len(r.Columns))
-				for index, column := range r.Columns {
+				r.columnMapping = make(map[string]int, len(r.Columns_))
+				for index, column := range r.Columns_ {
 					r.columnMapping[column] = index
 				}
 			}
@@ -94,7 +131,12 @@ func (r *result) parseKeys() error {
 		case "stats":
 			err = r.res.dec.Decode(&r.Stats)
 		default:
-			return errors.Errorf("found unexpected token: %v", t)
+			key, ok := t.(string)
+			if !ok {
+				return errors.Errorf("found unexpected token: %v", t)
+			}
+			debugLog("skipping unknown result key %q", key)
+			err = skipValue(r.res.dec)
 		}
 		if err != nil {
 			return errors.WithMessage(err, "failed to read key "+t.(string))
@@ -116,6 +158,9 @@ func (r *result) nextRow() error {
 	if r.consumed {
 		return nil
 	}
+	if r.res.jolt {
+		return r.nextJoltRow()
+	}
 	if !r.res.dec.More() {
 		r.lastRow = nil
 		_, err := r.res.dec.Token()
@@ -124,17 +169,24 @@ func (r *result) nextRow() error {
 		}
 		return r.parseKeys()
 	}
-	r.lastRow = &row{columns: r.columnMapping}
-	err := r.res.dec.Decode(&r.lastRow)
+	rw := &row{keys: r.Columns_, columns: r.columnMapping, elementIDs: r.res.caps.elementIDs, numbers: r.res.numbers}
+	err := r.res.dec.Decode(rw)
 	if err != nil {
 		return err
 	}
+	if rw.numbers == cypher.NumberInt64 {
+		rw.convertNumbers()
+	}
+	r.addToGraph(rw)
+	r.lastRow = rw
 	return nil
 }
 
 func (r *result) nextRowDone() bool {
 	if r.res.singleResult {
-		r.deferredErr = r.res.Consume()
+		if err := r.res.Consume(); r.deferredErr == nil {
+			r.deferredErr = err
+		}
 	}
 	return false
 }
@@ -0,0 +1,41 @@
+package neohttp
+
+import (
+	"github.com/tjbrockmeyer/cypher"
+	"testing"
+)
+
+func TestResultGraph(t *testing.T) {
+	s := newFakeServer(t, 3)
+	db := s.connect(t)
+	result := cypher.RunWith(db, cypher.RunOptions{Graph: true}, "MATCH (n) RETURN n", nil)
+	if _, err := result.Consume(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(result.Graph().Nodes); n != s.rows {
+		t.Errorf("the graph has %d nodes, expected %d", n, s.rows)
+	}
+
+	result = db.Run("MATCH (n) RETURN n", nil)
+	if _, err := result.Consume(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(result.Graph().Nodes); n != 0 {
+		t.Errorf("the graph has %d nodes without being requested", n)
+	}
+}
+
+func TestResultGraphOfJoltValues(t *testing.T) {
+	rw := &row{Row: []interface{}{cypher.Node{ID: 1}, []interface{}{cypher.Node{ID: 2}}}}
+	for _, requested := range []bool{false, true} {
+		r := &result{res: &response{jolt: true, graph: requested}}
+		r.addToGraph(rw)
+		expected := 0
+		if requested {
+			expected = 2
+		}
+		if n := len(r.Graph().Nodes); n != expected {
+			t.Errorf("with the graph requested: %v, the graph has %d nodes, expected %d", requested, n, expected)
+		}
+	}
+}
@@ -3,12 +3,64 @@ package neohttp
 import (
 	"bytes"
 	"encoding/json"
+	"github.com/tjbrockmeyer/cypher"
+	"github.com/tjbrockmeyer/cypher/internal/httpapi"
+	"strconv"
 )
 
 type row struct {
+	keys    []string
 	columns map[string]int
-	Row     []interface{} `json:"row"`
-	Meta    []interface{} `json:"meta"`
+	// Read the element ids from the metadata.
+	elementIDs bool
+	numbers    cypher.NumberMode
+	Row        []interface{} `json:"row"`
+	Meta       []interface{} `json:"meta"`
+	Graph      *rowGraph     `json:"graph"`
+}
+
+// The nodes and relationships of a row, when the graph format is requested.
+// Ids are sent as strings.
+type rowGraph struct {
+	Nodes []struct {
+		ID         string                 `json:"id"`
+		ElementID  string                 `json:"elementId"`
+		Labels     []string               `json:"labels"`
+		Properties map[string]interface{} `json:"properties"`
+	} `json:"nodes"`
+	Relationships []struct {
+		ID                 string                 `json:"id"`
+		ElementID          string                 `json:"elementId"`
+		Type               string                 `json:"type"`
+		StartNode          string                 `json:"startNode"`
+		StartNodeElementID string                 `json:"startNodeElementId"`
+		EndNode            string                 `json:"endNode"`
+		EndNodeElementID   string                 `json:"endNodeElementId"`
+		Properties         map[string]interface{} `json:"properties"`
+	} `json:"relationships"`
+}
+
+// Add the nodes and relationships of the row to the graph.
+func (g *rowGraph) addTo(graph *cypher.Graph) {
+	for _, n := range g.Nodes {
+		id, _ := strconv.ParseInt(n.ID, 10, 64)
+		graph.AddNode(cypher.Node{ID: id, ElementID: n.ElementID, Labels: n.Labels, Properties: n.Properties})
+	}
+	for _, r := range g.Relationships {
+		id, _ := strconv.ParseInt(r.ID, 10, 64)
+		start, _ := strconv.ParseInt(r.StartNode, 10, 64)
+		end, _ := strconv.ParseInt(r.EndNode, 10, 64)
+		graph.AddRelationship(cypher.Relationship{
+			ID:             id,
+			ElementID:      r.ElementID,
+			Type:           r.Type,
+			StartID:        start,
+			StartElementID: r.StartNodeElementID,
+			EndID:          end,
+			EndElementID:   r.EndNodeElementID,
+			Properties:     r.Properties,
+		})
+	}
 }
 
 func (r *row) GetAt(i int) interface{} {
@@ -19,19 +71,103 @@ func (r *row) Get(n string) interface{} {
 	return r.Row[r.columns[n]]
 }
 
+func (r *row) Keys() []string {
+	return r.keys
+}
+
+func (r *row) Values() []interface{} {
+	return r.Row
+}
+
+func (r *row) Len() int {
+	return len(r.Row)
+}
+
+func (r *row) AsMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(r.keys))
+	for i, name := range r.keys {
+		m[name] = r.Row[i]
+	}
+	return m
+}
+
+func (r *row) NumberMode() cypher.NumberMode {
+	return r.numbers
+}
+
+// Convert the json.Numbers decoded from the row into int64 or float64.
+func (r *row) convertNumbers() {
+	for i, value := range r.Row {
+		r.Row[i] = cypher.ConvertNumbers(value, cypher.NumberInt64)
+	}
+	if r.Graph == nil {
+		return
+	}
+	for _, n := range r.Graph.Nodes {
+		cypher.ConvertNumbers(n.Properties, cypher.NumberInt64)
+	}
+	for _, rel := range r.Graph.Relationships {
+		cypher.ConvertNumbers(rel.Properties, cypher.NumberInt64)
+	}
+}
+
 func (r *row) MarshalJSON() ([]byte, error) {
 	var buf bytes.Buffer
 	buf.WriteByte('{')
-	i := 0
-	for name, index := range r.columns {
-		buf.WriteString("\"" + name + "\":")
-		b, _ := json.Marshal(r.Row[index])
+	for i, name := range r.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		b, err := json.Marshal(name)
+		if err != nil {
+			return nil, errMsg(err, "failed to marshal column name "+name)
+		}
 		buf.Write(b)
-		if i < len(r.columns)-1 {
-			buf.WriteString(",")
-			i++
+		buf.WriteByte(':')
+		b, err = json.Marshal(r.Row[i])
+		if err != nil {
+			return nil, errMsg(err, "failed to marshal value of column "+name)
 		}
+		buf.Write(b)
 	}
 	buf.WriteByte('}')
 	return buf.Bytes(), nil
 }
+
+func (r *row) MetaAt(i int) interface{} {
+	if r.Meta == nil && i < len(r.Row) {
+		// Jolt has no separate metadata, as the graph values carry their own identity.
+		return httpapi.MetaOf(r.Row[i])
+	}
+	if i >= len(r.Meta) {
+		return nil
+	}
+	return convertMeta(r.Meta[i], r.elementIDs)
+}
+
+// Convert the json metadata of a value into its cypher.Meta representation.
+// Servers with element ids may stop reporting numeric ids, so either identifies the entity.
+func convertMeta(meta interface{}, elementIDs bool) interface{} {
+	switch m := meta.(type) {
+	case map[string]interface{}:
+		id, hasID := cypher.AsInt64(m["id"])
+		var elementID string
+		if elementIDs {
+			elementID, _ = m["elementId"].(string)
+		}
+		if !hasID && elementID == "" {
+			return nil
+		}
+		t, _ := m["type"].(string)
+		deleted, _ := m["deleted"].(bool)
+		return &cypher.Meta{ID: id, ElementID: elementID, Type: t, Deleted: deleted}
+	case []interface{}:
+		list := make([]interface{}, len(m))
+		for i, item := range m {
+			list[i] = convertMeta(item, elementIDs)
+		}
+		return list
+	default:
+		return nil
+	}
+}
@@ -1,5 +1,7 @@
 package neohttp
 
+import "encoding/json"
+
 type stats struct {
 	ContainsUpdates_ bool `json:"contains_updates"`
 	NodesCreated_ int `json:"nodes_created"`
@@ -18,6 +20,22 @@ type stats struct {
 	SystemUpdates_         int  `json:"system_updates"`
 }
 
+// Newer servers report deleted relationships as relationships_deleted.
+func (s *stats) UnmarshalJSON(b []byte) error {
+	type plain stats
+	aux := struct {
+		*plain
+		RelationshipsDeleted *int `json:"relationships_deleted"`
+	}{plain: (*plain)(s)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	if aux.RelationshipsDeleted != nil {
+		s.RelationshipDeleted_ = *aux.RelationshipsDeleted
+	}
+	return nil
+}
+
 func (s *stats) ConstraintsAdded() int {
 	return s.ConstraintsAdded_
 }
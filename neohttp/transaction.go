@@ -1,20 +1,57 @@
 package neohttp
 
 import (
+	"context"
+	"github.com/pkg/errors"
 	"github.com/tjbrockmeyer/cypher"
 	"strings"
+	"sync"
 )
 
+// Requests are serialized by sendMu, which is held while a request is sent and the start of its response is read.
+// The server accepts a request only once the response to the previous request has been received,
+// so before sending, the previous response is drained into memory for its reader to continue from,
+// or cancelled when the transaction is being rolled back.
 type transaction struct {
-	db       *database
+	db *database
+	// The context of the requests of the transaction, other than its rollback.
+	// Cancelled when the transaction is abandoned, so that it can be rolled back without waiting for them.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	sendMu sync.Mutex
+	// The last response, which may still be being received.
+	last *response
+
+	mu       sync.Mutex
 	id       string
 	location string
 	alive    bool
 }
 
+func newTransaction(db *database) *transaction {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &transaction{
+		db:     db,
+		ctx:    ctx,
+		cancel: cancel,
+		alive:  true,
+	}
+}
+
+var errRolledBack = errors.New("cypher/neohttp: the response was cancelled as the transaction was rolled back")
+
 func (tx *transaction) Run(statement string, params map[string]interface{}) cypher.Result {
-	res, runResult := tx.db.run(tx.id, statement, params)
+	return tx.RunWithOptions(cypher.RunOptions{}, statement, params)
+}
+
+func (tx *transaction) RunWithOptions(opts cypher.RunOptions, statement string, params map[string]interface{}) cypher.Result {
+	tx.takeTurn(false)
+	res, runResult := tx.db.run(tx.ctx, tx.getID(), statement, params, opts)
+	defer tx.endTurn(res)
+	res.whenDone(func() { tx.responseDone(res) })
 	if runResult.Err() != nil {
+		res.finish()
 		return runResult
 	}
 	if err := tx.handleResponse(res); err != nil {
@@ -24,11 +61,14 @@ func (tx *transaction) Run(statement string, params map[string]interface{}) cyph
 }
 
 func (tx *transaction) RunMany(cypherOrParams ...interface{}) cypher.Response {
-	r := tx.db.runMany(tx.id, cypherOrParams...)
-	if r.Err() != nil {
-		return r
+	tx.takeTurn(false)
+	res := tx.db.runMany(tx.ctx, tx.getID(), cypherOrParams...).(*response)
+	defer tx.endTurn(res)
+	res.whenDone(func() { tx.responseDone(res) })
+	if res.Err() != nil {
+		res.finish()
+		return res
 	}
-	res := r.(*response)
 	if err := tx.handleResponse(res); err != nil {
 		res.deferredErr = err
 	}
@@ -36,12 +76,17 @@ func (tx *transaction) RunMany(cypherOrParams ...interface{}) cypher.Response {
 }
 
 func (tx *transaction) Commit() error {
-	res := tx.db.getResponse("POST", tx.id+"/commit", request{Statements: []query{}})
+	defer tx.db.txs.Untrack(tx)
+	tx.takeTurn(false)
+	res := tx.db.getResponseContext(tx.ctx, "POST", tx.getID()+"/commit", request{Statements: []query{}})
+	defer tx.endTurn(res)
+	res.whenDone(func() { tx.responseDone(res) })
 	if res.deferredErr != nil {
+		res.finish()
 		return errMsg(res.deferredErr, "error during commit request")
 	}
 	if err := res.Consume(); err != nil {
-		tx.alive = false
+		tx.setAlive(false)
 		return err
 	}
 	if err := tx.handleResponse(res); err != nil {
@@ -50,10 +95,22 @@ func (tx *transaction) Commit() error {
 	return nil
 }
 
+// Roll back the transaction. A response which is still being read is cancelled rather than waited for.
 func (tx *transaction) Rollback() error {
-	res := tx.db.getResponse("DELETE", tx.id, request{Statements: []query{}})
+	defer tx.db.txs.Untrack(tx)
+	tx.takeTurn(true)
+	id := tx.getID()
+	if id == "" {
+		// Nothing was ever run, so the transaction was never opened on the server.
+		tx.endTurn(nil)
+		tx.setAlive(false)
+		return nil
+	}
+	res := tx.db.getResponse("DELETE", id, request{Statements: []query{}})
+	defer tx.endTurn(res)
+	res.whenDone(func() { tx.responseDone(res) })
 	if err := res.Consume(); err != nil {
-		tx.alive = false
+		tx.setAlive(false)
 		return err
 	}
 	if err := tx.handleResponse(res); err != nil {
@@ -62,11 +119,62 @@ func (tx *transaction) Rollback() error {
 	return nil
 }
 
+// Roll back a transaction which was left open when the database is closed,
+// cancelling any request which is still being sent or read.
+func (tx *transaction) abandon() error {
+	tx.cancel()
+	return tx.Rollback()
+}
+
+// Wait for any request being sent, then settle the last response: drain it, or cancel it if rolling back.
+func (tx *transaction) takeTurn(rollback bool) {
+	tx.sendMu.Lock()
+	if tx.last != nil {
+		if rollback {
+			tx.last.body.cancel(errRolledBack)
+		} else {
+			tx.last.body.drain()
+		}
+		tx.last = nil
+	}
+}
+
+func (tx *transaction) endTurn(res *response) {
+	tx.last = res
+	tx.sendMu.Unlock()
+}
+
+// Once a response has been read, the transaction is alive only if the server reported it as still open.
+// The server rolls back a transaction when one of its statements fails.
+func (tx *transaction) responseDone(res *response) {
+	if res.consumed {
+		tx.setAlive(res.transaction != nil)
+	}
+}
+
+func (tx *transaction) getID() string {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	return tx.id
+}
+
+func (tx *transaction) setAlive(alive bool) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.alive = alive
+	if !alive {
+		tx.db.txs.Untrack(tx)
+	}
+}
+
 func (tx *transaction) handleResponse(res *response) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
 	if tx.id == "" {
-		tx.location = res.header.Get("Location")
-		tx.id = tx.location[strings.LastIndex(tx.location, "/"):]
+		if location := res.header.Get("Location"); location != "" {
+			tx.location = location
+			tx.id = location[strings.LastIndex(location, "/"):]
+		}
 	}
-	tx.alive = res.transaction != nil
 	return nil
 }
@@ -0,0 +1,292 @@
+package neohttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/tjbrockmeyer/cypher"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A stand-in for the transaction endpoints of the http api.
+// Like neo4j, it fails a request on a transaction while the response to the previous request is being sent.
+// Each statement returns the numbers 1 to rows in the column n, except HANG, which never responds.
+// When the graph format is requested, each row has a node with the id of its number.
+type fakeServer struct {
+	*httptest.Server
+	rows int
+	// Receives once a HANG statement has been received.
+	hung chan struct{}
+	// Closed when the test ends, so that HANG statements end with it.
+	stop chan struct{}
+
+	mu sync.Mutex
+	// The number of requests for the discovery document which fail before one succeeds.
+	unavailable int
+	nextID      int
+	sending     map[string]bool
+	errs        []string
+	rolledBack  int
+}
+
+func newFakeServer(t *testing.T, rows int) *fakeServer {
+	s := &fakeServer{rows: rows, hung: make(chan struct{}, 1), stop: make(chan struct{}), sending: make(map[string]bool)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(func() {
+		close(s.stop)
+		s.Close()
+	})
+	return s
+}
+
+func (s *fakeServer) connect(t *testing.T) *database {
+	db, err := NewDriver(Options{}).Connect(s.URL, "neo4j", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db.(*database)
+}
+
+// The requests which were sent on a transaction while the response to its previous request was being sent.
+func (s *fakeServer) overlaps() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.errs
+}
+
+func (s *fakeServer) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/" {
+		s.mu.Lock()
+		unavailable := s.unavailable > 0
+		s.unavailable--
+		s.mu.Unlock()
+		if unavailable {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"transaction":   s.URL + "/db/{databaseName}/tx",
+			"neo4j_version": "4.4.0",
+		})
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/db/neo4j/tx")
+	id := strings.TrimSuffix(strings.TrimPrefix(path, "/"), "/commit")
+	var req request
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	s.mu.Lock()
+	if id == "" {
+		s.nextID++
+		id = fmt.Sprint(s.nextID)
+		w.Header().Set("Location", s.URL+"/db/neo4j/tx/"+id)
+	} else if id != "commit" && s.sending[id] {
+		s.errs = append(s.errs, r.Method+" "+r.URL.Path)
+	}
+	s.sending[id] = true
+	if r.Method == "DELETE" {
+		s.rolledBack++
+	}
+	s.mu.Unlock()
+
+	if len(req.Statements) > 0 && req.Statements[0].Statement == "HANG" {
+		s.hung <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-s.stop:
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	var b strings.Builder
+	b.WriteString(`{"results":[`)
+	for i, q := range req.Statements {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(`{"columns":["n"],"data":[`)
+		for n := 1; n <= s.rows; n++ {
+			if n > 1 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, `{"row":[%d],"meta":[null]`, n)
+			if len(q.ResultDataContents) > 1 {
+				fmt.Fprintf(&b, `,"graph":{"nodes":[{"id":"%d","labels":["N"],"properties":{}}],"relationships":[]}`, n)
+			}
+			b.WriteString(`}`)
+		}
+		b.WriteString(`]}`)
+	}
+	b.WriteString(`],"errors":[]`)
+	if r.Method == "POST" && !strings.HasSuffix(path, "/commit") {
+		b.WriteString(`,"transaction":{"expires":"Mon, 1 Jan 2100 00:00:00 GMT"}`)
+	}
+	_, _ = w.Write([]byte(b.String()))
+	w.(http.Flusher).Flush()
+	// The response is done before its last byte is received, so a client which waits for the whole response
+	// never sends a request which overlaps it.
+	s.mu.Lock()
+	s.sending[id] = false
+	s.mu.Unlock()
+	_, _ = w.Write([]byte(`}`))
+}
+
+func (s *fakeServer) rollbacks() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rolledBack
+}
+
+// Fail the test if f does not return in time.
+func within(t *testing.T, what string, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s did not return", what)
+	}
+}
+
+func TestTransactionConcurrentStatements(t *testing.T) {
+	s := newFakeServer(t, 500)
+	db := s.connect(t)
+	tx, err := db.TX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			switch {
+			case i%4 == 3:
+				err = tx.RunMany("RETURN 1", "RETURN 2").Consume()
+			default:
+				var rows []cypher.Row
+				rows, err = cypher.Collect(tx.Run("RETURN 1", nil))
+				if err == nil && len(rows) != s.rows {
+					err = fmt.Errorf("read %d rows, expected %d", len(rows), s.rows)
+				}
+			}
+			errs <- err
+		}(i)
+	}
+	within(t, "concurrent statements", wg.Wait)
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	within(t, "Commit", func() {
+		if err := tx.Commit(); err != nil {
+			t.Error(err)
+		}
+	})
+	if overlaps := s.overlaps(); len(overlaps) > 0 {
+		t.Errorf("requests were sent before the previous response was received: %v", overlaps)
+	}
+}
+
+func TestTransactionConcurrentRunAndCommit(t *testing.T) {
+	s := newFakeServer(t, 500)
+	db := s.connect(t)
+	tx, err := db.TX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Run("RETURN 1", nil).Consume(); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		// Run either before the commit, or after it and fails.
+		_, _ = cypher.Collect(tx.Run("RETURN 1", nil))
+	}()
+	go func() {
+		defer wg.Done()
+		if err := tx.Commit(); err != nil {
+			t.Error(err)
+		}
+	}()
+	within(t, "Run and Commit", wg.Wait)
+	if overlaps := s.overlaps(); len(overlaps) > 0 {
+		t.Errorf("requests were sent before the previous response was received: %v", overlaps)
+	}
+}
+
+func TestTransactionUnreadResult(t *testing.T) {
+	s := newFakeServer(t, 5000)
+	db := s.connect(t)
+	tx, err := db.TX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := tx.Run("RETURN 1", nil)
+	var second cypher.Result
+	within(t, "Run after an unread result", func() {
+		second = tx.Run("RETURN 2", nil)
+	})
+	// The unread result was drained and may still be read.
+	for _, result := range []cypher.Result{first, second} {
+		rows, err := cypher.Collect(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != s.rows {
+			t.Fatalf("read %d rows, expected %d", len(rows), s.rows)
+		}
+	}
+	tx.Run("RETURN 3", nil)
+	within(t, "Commit after an unread result", func() {
+		if err := tx.Commit(); err != nil {
+			t.Error(err)
+		}
+	})
+	within(t, "TXJob with an unread result", func() {
+		_, err := db.TXJob(func(tx cypher.Transaction) (interface{}, error) {
+			return tx.Run("RETURN 1", nil), nil
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	if overlaps := s.overlaps(); len(overlaps) > 0 {
+		t.Errorf("requests were sent before the previous response was received: %v", overlaps)
+	}
+}
+
+func TestTransactionRollbackCancelsUnreadResult(t *testing.T) {
+	s := newFakeServer(t, 5000)
+	db := s.connect(t)
+	tx, err := db.TX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := tx.Run("RETURN 1", nil)
+	within(t, "Rollback after an unread result", func() {
+		if err := tx.Rollback(); err != nil {
+			t.Error(err)
+		}
+	})
+	if _, err = cypher.Collect(result); err == nil {
+		t.Error("reading a result cancelled by a rollback did not fail")
+	}
+	if db.OpenTransactions() != 0 {
+		t.Errorf("%d transactions are still open", db.OpenTransactions())
+	}
+}
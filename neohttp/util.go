@@ -2,20 +2,13 @@ package neohttp
 
 import (
 	"github.com/pkg/errors"
-	"github.com/tjbrockmeyer/cypher"
-	"log"
+	"github.com/tjbrockmeyer/cypher/internal/httpapi"
 )
 
-func errMsg(err error, message string) error {
-	return errors.WithMessage(err, message)
-}
+const logger = httpapi.Logger("cypher/neohttp")
 
-func writeLog(prefix, format string, args ...interface{}) {
-	log.Printf(prefix+"cypher/neohttp: "+format, args...)
-}
-
-func debugLog(format string, args ...interface{}) {
-	if cypher.Debug {
-		writeLog("[DEBUG] ", format, args...)
-	}
-}
+var (
+	errMsg   = errors.WithMessage
+	writeLog = logger.Printf
+	debugLog = logger.Debugf
+)
@@ -0,0 +1,37 @@
+package neohttp
+
+import (
+	"sort"
+	"strings"
+)
+
+// The behavior of the http api which differs between major versions of neo4j.
+type capabilities struct {
+	// The metadata of nodes and relationships includes their element ids.
+	elementIDs bool
+	// Responses which commit a transaction include its bookmarks under the lastBookmarks key.
+	bookmarks bool
+	// The media type of the jolt format. Element ids replace numeric ids in version 2.
+	jolt string
+}
+
+// The capabilities of each supported major version of neo4j.
+var versionCapabilities = map[string]capabilities{
+	"4": {jolt: "application/vnd.neo4j.jolt"},
+	"5": {elementIDs: true, bookmarks: true, jolt: "application/vnd.neo4j.jolt-v2"},
+}
+
+// Get the capabilities of a server by its version, as reported by the discovery document.
+func capabilitiesOf(version string) (capabilities, bool) {
+	c, ok := versionCapabilities[strings.Split(version, ".")[0]]
+	return c, ok
+}
+
+func supportedMajorVersions() []string {
+	versions := make([]string, 0, len(versionCapabilities))
+	for v := range versionCapabilities {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
@@ -0,0 +1,222 @@
+package neoquery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"github.com/tjbrockmeyer/cypher/internal/httpapi"
+	"io/ioutil"
+	"strings"
+)
+
+// The header which routes the requests of a transaction to the cluster member which holds it.
+const affinityHeader = "neo4j-cluster-affinity"
+
+// The media type of the typed json format, for both requests and responses.
+const typedJSON = "application/vnd.neo4j.query"
+
+type database struct {
+	uri       string
+	name      string
+	client    httpapi.Client
+	discovery discovery
+
+	txs httpapi.Transactions[*transaction]
+}
+
+func (db *database) Run(statement string, params map[string]interface{}) cypher.Result {
+	res, _, err := db.query(context.Background(), "POST", "", "", statement, params)
+	if err != nil {
+		return &result{err: err}
+	}
+	return res.result(0)
+}
+
+// Run the statements in a single transaction, which is committed if all of them succeed.
+func (db *database) RunMany(cypherOrParams ...interface{}) cypher.Response {
+	tx := newTransaction(db)
+	res := tx.runMany(cypherOrParams...)
+	if res.err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			debugLog("failed to roll back: %v", rbErr)
+		}
+		return res
+	}
+	if err := tx.Commit(); err != nil {
+		res.err = errMsg(err, "error during commit")
+		return res
+	}
+	res.bookmarks = tx.bookmarks
+	return res
+}
+
+func (db *database) TX() (cypher.Transaction, error) {
+	return db.txs.Track(newTransaction(db)), nil
+}
+
+func (db *database) TXJob(job func(tx cypher.Transaction) (interface{}, error)) (interface{}, error) {
+	tx := db.txs.Track(newTransaction(db))
+	val, err := job(tx)
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return nil, errMsg(rbErr, "error during rollback")
+		}
+		return nil, errMsg(err, "error during TX job")
+	}
+	return val, errMsg(tx.Commit(), "error during commit")
+}
+
+func (db *database) Ping(ctx context.Context) error {
+	if _, _, err := db.query(ctx, "POST", "", "", "RETURN 1", nil); err != nil {
+		return errMsg(err, "ping failed")
+	}
+	return nil
+}
+
+func (db *database) ServerInfo() cypher.ServerInfo {
+	d := db.discovery
+	return httpapi.ServerInfo(db.uri, db.name, d.Version, d.Edition, map[string]string{
+		"bolt_direct":  d.BoltDirect,
+		"bolt_routing": d.BoltRouting,
+		"transaction":  d.TX,
+		"query":        d.Query,
+	})
+}
+
+func (db *database) OpenTransactions() int {
+	return db.txs.Len()
+}
+
+func (db *database) Close() error {
+	return db.txs.Close(logger, (*transaction).abandon)
+}
+
+type request struct {
+	Statement       string                 `json:"statement,omitempty"`
+	Parameters      map[string]interface{} `json:"parameters,omitempty"`
+	IncludeCounters bool                   `json:"includeCounters,omitempty"`
+}
+
+// The body of every response of the query api, of which only some keys are present.
+type queryResponse struct {
+	Data *struct {
+		Fields []string            `json:"fields"`
+		Values [][]json.RawMessage `json:"values"`
+	} `json:"data"`
+	Counters      counters              `json:"counters"`
+	Bookmarks     []string              `json:"bookmarks"`
+	Notifications []cypher.Notification `json:"notifications"`
+	Transaction   *struct {
+		ID      string `json:"id"`
+		Expires string `json:"expires"`
+	} `json:"transaction"`
+	Errors []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Returns any attached errors found as an error.
+func (r *queryResponse) getErrors() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	b := strings.Builder{}
+	for i, err := range r.Errors {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(err.Code + ": " + err.Message)
+	}
+	return errors.New(b.String())
+}
+
+// Whether the errors of the response mean that the server has rolled back the transaction of the request,
+// or no longer has it. Errors authenticating the request leave the transaction as it was.
+func (r *queryResponse) endsTransaction() bool {
+	for _, err := range r.Errors {
+		if !strings.HasPrefix(err.Code, "Neo.ClientError.Security.") {
+			return true
+		}
+	}
+	return false
+}
+
+// Convert the data of the response into a result.
+func (r *queryResponse) result(index int) *result {
+	res := &result{index: index, stats: r.Counters}
+	if r.Data == nil {
+		return res
+	}
+	res.columns = r.Data.Fields
+	res.columnMapping = make(map[string]int, len(res.columns))
+	for i, column := range res.columns {
+		res.columnMapping[column] = i
+	}
+	res.rows = make([]*row, len(r.Data.Values))
+	for i, values := range r.Data.Values {
+		rw := &row{keys: res.columns, columns: res.columnMapping, values: make([]interface{}, len(values))}
+		for j, raw := range values {
+			v, err := decodeValue(raw)
+			if err != nil {
+				res.err = errMsg(err, "failed to decode the value of column "+res.columns[j])
+				res.rows = res.rows[:i]
+				return res
+			}
+			rw.values[j] = v
+		}
+		res.rows[i] = rw
+	}
+	return res
+}
+
+// Send a statement to the query endpoint, or a transaction endpoint relative to it.
+// Returns the response and the cluster affinity given by the server.
+func (db *database) query(ctx context.Context, method, relPath, affinity, statement string, params map[string]interface{}) (*queryResponse, string, error) {
+	body := request{Statement: statement, IncludeCounters: statement != ""}
+	if params != nil {
+		typed, err := encodeParams(params)
+		if err != nil {
+			return nil, "", errMsg(err, "could not encode parameters")
+		}
+		body.Parameters = typed
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, "", errors.WithMessage(err, "could not marshal request body")
+	}
+	endpoint := db.discovery.Query + relPath
+	debugLog("requesting %s %s with payload %s", method, endpoint, string(b))
+	header := map[string]string{
+		"Accept":       typedJSON + ", application/json",
+		"Content-Type": typedJSON,
+	}
+	if affinity != "" {
+		header[affinityHeader] = affinity
+	}
+	res, err := db.client.Do(ctx, method, endpoint, b, header)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, "", errors.WithMessage(err, "failed to read response body")
+	}
+	debugLog("response received with status %v: %s", res.StatusCode, string(resBody))
+	qr := new(queryResponse)
+	if len(bytes.TrimSpace(resBody)) != 0 {
+		if err = json.Unmarshal(resBody, qr); err != nil {
+			return nil, "", errors.WithMessagef(err, "failed to unmarshal response body with status %v", res.StatusCode)
+		}
+	}
+	if err = qr.getErrors(); err != nil {
+		return qr, "", errMsg(err, "database returned errors")
+	}
+	if res.StatusCode >= 400 {
+		return qr, "", errors.Errorf("server responded with status %v", res.StatusCode)
+	}
+	return qr, res.Header.Get(affinityHeader), nil
+}
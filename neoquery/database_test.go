@@ -0,0 +1,264 @@
+package neoquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/tjbrockmeyer/cypher"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A stand-in for the query api. Each statement returns the integer 1 in the column n, except
+// ECHO, which returns its parameters as received in columns named after them,
+// HANG, which never responds, and FAIL, which fails with a syntax error and rolls back its transaction.
+type fakeServer struct {
+	*httptest.Server
+	// Receives once a HANG statement has been received.
+	hung chan struct{}
+	// Closed when the test ends, so that HANG statements end with it.
+	stop chan struct{}
+
+	mu         sync.Mutex
+	nextID     int
+	open       map[string]bool
+	rolledBack int
+	statements []string
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	s := &fakeServer{hung: make(chan struct{}, 1), stop: make(chan struct{}), open: make(map[string]bool)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(func() {
+		close(s.stop)
+		s.Close()
+	})
+	return s
+}
+
+func (s *fakeServer) connect(t *testing.T) *database {
+	db, err := NewDriver(Options{}).Connect(s.URL, "neo4j", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db.(*database)
+}
+
+// The number of transactions which were opened.
+func (s *fakeServer) opened() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nextID
+}
+
+func (s *fakeServer) rollbacks() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rolledBack
+}
+
+func (s *fakeServer) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/" {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"query":         "/db/{databaseName}/query/v2",
+			"transaction":   s.URL + "/db/{databaseName}/tx",
+			"neo4j_version": "5.26.0",
+		})
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/db/neo4j/query/v2")
+	var req struct {
+		Statement  string                     `json:"statement"`
+		Parameters map[string]json.RawMessage `json:"parameters"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	w.Header().Set("Content-Type", typedJSON)
+
+	s.mu.Lock()
+	s.statements = append(s.statements, req.Statement)
+	var id string
+	switch {
+	case path == "/tx":
+		s.nextID++
+		id = fmt.Sprint(s.nextID)
+		s.open[id] = true
+	case strings.HasPrefix(path, "/tx/"):
+		id = strings.TrimSuffix(strings.TrimPrefix(path, "/tx/"), "/commit")
+		if !s.open[id] {
+			s.mu.Unlock()
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[{"code":"Neo.ClientError.Transaction.TransactionNotFound","message":"not found"}]}`))
+			return
+		}
+		if r.Method == "DELETE" {
+			s.rolledBack++
+		}
+		if r.Method == "DELETE" || strings.HasSuffix(path, "/commit") {
+			delete(s.open, id)
+		}
+	}
+	if req.Statement == "FAIL" {
+		delete(s.open, id)
+	}
+	s.mu.Unlock()
+
+	switch {
+	case req.Statement == "HANG":
+		s.hung <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-s.stop:
+		}
+	case req.Statement == "FAIL":
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"errors":[{"code":"Neo.ClientError.Statement.SyntaxError","message":"invalid input"}]}`))
+	case strings.HasSuffix(path, "/commit"):
+		_, _ = w.Write([]byte(`{"bookmarks":["bookmark:` + id + `"]}`))
+	case r.Method == "DELETE":
+		_, _ = w.Write([]byte(`{}`))
+	case req.Statement == "ECHO":
+		names := make([]string, 0, len(req.Parameters))
+		for name := range req.Parameters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		values := make([]json.RawMessage, len(names))
+		for i, name := range names {
+			values[i] = req.Parameters[name]
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"fields": names, "values": [][]json.RawMessage{values}},
+		})
+	default:
+		tx := ""
+		if id != "" {
+			tx = `,"transaction":{"id":"` + id + `","expires":"2100-01-01T00:00:00Z"}`
+		}
+		_, _ = w.Write([]byte(`{"data":{"fields":["n"],"values":[[{"$type":"Integer","_value":"1"}]]},` +
+			`"counters":{"containsUpdates":false}` + tx + `}`))
+	}
+}
+
+// Fail the test if f does not return in time.
+func within(t *testing.T, what string, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s did not return", what)
+	}
+}
+
+func TestCloseRollsBackAbandonedTransactions(t *testing.T) {
+	s := newFakeServer(t)
+	db := s.connect(t)
+	tx, err := db.TX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Run("RETURN 1", nil).Consume(); err != nil {
+		t.Fatal(err)
+	}
+	hung := make(chan error)
+	go func() {
+		_, err := tx.Run("HANG", nil).Consume()
+		hung <- err
+	}()
+	<-s.hung
+
+	within(t, "Close", func() {
+		if err := db.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	if err = <-hung; err == nil {
+		t.Error("a request cancelled by Close did not fail")
+	}
+	if n := s.rollbacks(); n != 1 {
+		t.Errorf("rolled back %d transactions, expected 1", n)
+	}
+	if db.OpenTransactions() != 0 {
+		t.Errorf("%d transactions are still open", db.OpenTransactions())
+	}
+}
+
+func TestRun(t *testing.T) {
+	s := newFakeServer(t)
+	db := s.connect(t)
+	row, err := cypher.Single(db.Run("RETURN 1 AS n", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := row.Get("n"); n != int64(1) {
+		t.Errorf("n is %#v, expected int64(1)", n)
+	}
+}
+
+func TestRunParameters(t *testing.T) {
+	s := newFakeServer(t)
+	db := s.connect(t)
+	date := time.Date(2024, 2, 29, 13, 30, 0, 0, time.UTC)
+	params := map[string]interface{}{
+		"big":    int64(1<<62 + 1),
+		"bool":   true,
+		"bytes":  []byte("abc"),
+		"float":  2.0,
+		"list":   []interface{}{int64(1), "two"},
+		"map":    map[string]interface{}{"a": int64(1)},
+		"null":   nil,
+		"string": "s",
+		"time":   date,
+	}
+	row, err := cypher.Single(db.Run("ECHO", params))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range params {
+		if actual := row.Get(name); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s is %#v, expected %#v", name, actual, expected)
+		}
+	}
+}
+
+func TestRunMany(t *testing.T) {
+	s := newFakeServer(t)
+	db := s.connect(t)
+	res := db.RunMany("RETURN 1", "ECHO", map[string]interface{}{"x": "y"})
+	var columns [][]string
+	for res.NextResult() {
+		columns = append(columns, res.GetResult().Columns())
+	}
+	if err := res.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(columns, [][]string{{"n"}, {"x"}}) {
+		t.Errorf("columns are %v", columns)
+	}
+	if bookmarks := res.LastBookmarks(); len(bookmarks) != 1 {
+		t.Errorf("bookmarks are %v, expected the bookmark of the commit", bookmarks)
+	}
+	if db.OpenTransactions() != 0 {
+		t.Errorf("%d transactions are still open", db.OpenTransactions())
+	}
+}
+
+func TestServerInfo(t *testing.T) {
+	s := newFakeServer(t)
+	info := s.connect(t).ServerInfo()
+	if info.Version != "5.26.0" || info.Database != "neo4j" || info.Protocol != "http" {
+		t.Errorf("server info is %+v", info)
+	}
+	if query := info.Endpoints["query"]; query != s.URL+"/db/neo4j/query/v2" {
+		t.Errorf("query endpoint is %q", query)
+	}
+}
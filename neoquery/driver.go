@@ -0,0 +1,94 @@
+// Package neoquery implements a driver for neo4j via the Query API, served at /db/{name}/query/v2 by neo4j 5.19 and later.
+// Use with package cypher by importing this package as _ and connecting using cypher.Connect("neoquery", ...)
+//
+// Parameters and results are exchanged in the typed json format of the Query API, so integers keep their precision,
+// and temporal, spatial and graph values keep their types. See decodeValue for the Go types of results.
+package neoquery
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"github.com/tjbrockmeyer/cypher/internal/httpapi"
+	"github.com/tjbrockmeyer/cypher/neohttp"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+func init() {
+	cypher.Register("neoquery", NewDriver(Options{}))
+}
+
+type Options struct {
+	// Supplies the credentials of each request. When nil, basic auth is used with the username and password
+	// given to Connect, or no auth if the username is empty.
+	Auth neohttp.AuthProvider
+}
+
+type driver struct {
+	opts Options
+}
+
+// Create a driver which connects with the given options.
+func NewDriver(opts Options) cypher.Driver {
+	return driver{opts: opts}
+}
+
+func (d driver) Connect(uri, dbName, username, password string) (cypher.DB, error) {
+	auth := d.opts.Auth
+	if auth == nil && username != "" {
+		auth = neohttp.BasicAuth(username, password)
+	}
+	db := &database{
+		uri:    strings.TrimSuffix(uri, "/"),
+		name:   dbName,
+		client: httpapi.Client{Auth: auth, Log: logger},
+	}
+	if err := db.discover(context.Background()); err != nil {
+		return nil, errMsg(err, "failed to connect")
+	}
+	return db, nil
+}
+
+// The discovery document served at the root of the http api.
+type discovery struct {
+	BoltDirect  string `json:"bolt_direct"`
+	BoltRouting string `json:"bolt_routing"`
+	TX          string `json:"transaction"`
+	Query       string `json:"query"`
+	Version     string `json:"neo4j_version"`
+	Edition     string `json:"neo4j_edition"`
+}
+
+func (db *database) discover(ctx context.Context) error {
+	debugLog("connecting to the database via http(s) at (%s)", db.uri)
+	res, err := db.client.Do(ctx, "GET", db.uri, nil, nil)
+	if err != nil {
+		return errMsg(err, "failed to get at uri ("+db.uri+")")
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return errMsg(err, "failed to read response body from uri ("+db.uri+")")
+	}
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("server responded with status %v: %s", res.StatusCode, body)
+	}
+	var d discovery
+	if err = json.Unmarshal(body, &d); err != nil {
+		return errMsg(err, "failed to unmarshal response body at uri ("+db.uri+")")
+	}
+	if d.Query == "" {
+		return errors.New("cypher/neoquery: the server (neo4j " + d.Version + ") does not serve the query api")
+	}
+	if strings.HasPrefix(d.Query, "/") {
+		d.Query = db.uri + d.Query
+	}
+	d.Query = strings.Replace(d.Query, "{databaseName}", db.name, 1)
+	d.TX = strings.Replace(d.TX, "{databaseName}", db.name, 1)
+	db.discovery = d
+	debugLog("successfully connected to the database at (%s)", db.uri)
+	return nil
+}
@@ -0,0 +1,45 @@
+package neoquery
+
+import "github.com/tjbrockmeyer/cypher"
+
+// The results of the statements of RunMany, which are read in full before it returns.
+type response struct {
+	results       []*result
+	next          int
+	lastResult    *result
+	err           error
+	notifications []cypher.Notification
+	bookmarks     []string
+}
+
+func (r *response) NextResult() bool {
+	if r.next >= len(r.results) {
+		r.lastResult = nil
+		return false
+	}
+	r.lastResult = r.results[r.next]
+	r.next++
+	return true
+}
+
+func (r *response) GetResult() cypher.Result {
+	return r.lastResult
+}
+
+func (r *response) Err() error {
+	return r.err
+}
+
+func (r *response) Consume() error {
+	r.next = len(r.results)
+	r.lastResult = nil
+	return r.err
+}
+
+func (r *response) Notifications() []cypher.Notification {
+	return r.notifications
+}
+
+func (r *response) LastBookmarks() []string {
+	return r.bookmarks
+}
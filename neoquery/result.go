@@ -0,0 +1,67 @@
+package neoquery
+
+import "github.com/tjbrockmeyer/cypher"
+
+// The result of a single statement, which is read in full before it is returned.
+type result struct {
+	index         int
+	columns       []string
+	columnMapping map[string]int
+	rows          []*row
+	next          int
+	lastRow       *row
+	stats         counters
+	graph         *cypher.Graph
+	err           error
+}
+
+func (r *result) Index() int {
+	return r.index
+}
+
+func (r *result) Columns() []string {
+	return r.columns
+}
+
+func (r *result) NextRow() bool {
+	if r.next >= len(r.rows) {
+		r.lastRow = nil
+		return false
+	}
+	r.lastRow = r.rows[r.next]
+	r.next++
+	return true
+}
+
+func (r *result) GetRow() cypher.Row {
+	if r.lastRow == nil {
+		return nil
+	}
+	return r.lastRow
+}
+
+func (r *result) Err() error {
+	return r.err
+}
+
+func (r *result) Consume() (cypher.Stats, error) {
+	r.next = len(r.rows)
+	r.lastRow = nil
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &r.stats, nil
+}
+
+// As the rows are read in full, the graph includes the values of every row.
+func (r *result) Graph() *cypher.Graph {
+	if r.graph == nil {
+		r.graph = new(cypher.Graph)
+		for _, row := range r.rows {
+			for _, value := range row.values {
+				r.graph.AddValue(value)
+			}
+		}
+	}
+	return r.graph
+}
@@ -0,0 +1,79 @@
+package neoquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/tjbrockmeyer/cypher"
+	"github.com/tjbrockmeyer/cypher/internal/httpapi"
+)
+
+type row struct {
+	keys    []string
+	columns map[string]int
+	values  []interface{}
+}
+
+func (r *row) GetAt(i int) interface{} {
+	return r.values[i]
+}
+
+func (r *row) Get(n string) interface{} {
+	return r.values[r.columns[n]]
+}
+
+func (r *row) Keys() []string {
+	return r.keys
+}
+
+func (r *row) Values() []interface{} {
+	return r.values
+}
+
+func (r *row) Len() int {
+	return len(r.values)
+}
+
+func (r *row) AsMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(r.keys))
+	for i, name := range r.keys {
+		m[name] = r.values[i]
+	}
+	return m
+}
+
+// Integers are always int64, as the values of the query api are tagged with their types.
+func (r *row) NumberMode() cypher.NumberMode {
+	return cypher.NumberInt64
+}
+
+func (r *row) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range r.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		b, err := json.Marshal(name)
+		if err != nil {
+			return nil, errMsg(err, "failed to marshal column name "+name)
+		}
+		buf.Write(b)
+		buf.WriteByte(':')
+		b, err = json.Marshal(r.values[i])
+		if err != nil {
+			return nil, errMsg(err, "failed to marshal value of column "+name)
+		}
+		buf.Write(b)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// The metadata is derived from the graph values, as the query api has no separate metadata.
+// The numeric ids of the metadata are always 0, as the query api reports element ids only.
+func (r *row) MetaAt(i int) interface{} {
+	if i >= len(r.values) {
+		return nil
+	}
+	return httpapi.MetaOf(r.values[i])
+}
@@ -0,0 +1,74 @@
+package neoquery
+
+type counters struct {
+	ContainsUpdates_       bool `json:"containsUpdates"`
+	NodesCreated_          int  `json:"nodesCreated"`
+	NodesDeleted_          int  `json:"nodesDeleted"`
+	PropertiesSet_         int  `json:"propertiesSet"`
+	RelationshipsCreated_  int  `json:"relationshipsCreated"`
+	RelationshipsDeleted_  int  `json:"relationshipsDeleted"`
+	LabelsAdded_           int  `json:"labelsAdded"`
+	LabelsRemoved_         int  `json:"labelsRemoved"`
+	IndexesAdded_          int  `json:"indexesAdded"`
+	IndexesRemoved_        int  `json:"indexesRemoved"`
+	ConstraintsAdded_      int  `json:"constraintsAdded"`
+	ConstraintsRemoved_    int  `json:"constraintsRemoved"`
+	ContainsSystemUpdates_ bool `json:"containsSystemUpdates"`
+	SystemUpdates_         int  `json:"systemUpdates"`
+}
+
+func (s *counters) ConstraintsAdded() int {
+	return s.ConstraintsAdded_
+}
+
+func (s *counters) ConstraintsRemoved() int {
+	return s.ConstraintsRemoved_
+}
+
+func (s *counters) ContainsUpdates() bool {
+	return s.ContainsUpdates_
+}
+
+func (s *counters) IndexesAdded() int {
+	return s.IndexesAdded_
+}
+
+func (s *counters) IndexesRemoved() int {
+	return s.IndexesRemoved_
+}
+
+func (s *counters) LabelsAdded() int {
+	return s.LabelsAdded_
+}
+
+func (s *counters) LabelsRemoved() int {
+	return s.LabelsRemoved_
+}
+
+func (s *counters) NodesCreated() int {
+	return s.NodesCreated_
+}
+
+func (s *counters) NodesDeleted() int {
+	return s.NodesDeleted_
+}
+
+func (s *counters) PropertiesSet() int {
+	return s.PropertiesSet_
+}
+
+func (s *counters) RelationshipDeleted() int {
+	return s.RelationshipsDeleted_
+}
+
+func (s *counters) RelationshipsCreated() int {
+	return s.RelationshipsCreated_
+}
+
+func (s *counters) ContainsSystemUpdates() bool {
+	return s.ContainsSystemUpdates_
+}
+
+func (s *counters) SystemUpdates() int {
+	return s.SystemUpdates_
+}
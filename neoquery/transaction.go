@@ -0,0 +1,161 @@
+package neoquery
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"sync"
+)
+
+// The transaction is opened on the server by its first statement.
+// Statements are serialized by the mutex, which is held for the whole of each request.
+type transaction struct {
+	db *database
+	// The context of the requests of the transaction, other than its rollback.
+	// Cancelled when the transaction is abandoned, so that it can be rolled back without waiting for them.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	id       string
+	affinity string
+	// Set once the transaction has been committed or rolled back, by the client or by the server after an error.
+	closed    bool
+	bookmarks []string
+}
+
+func newTransaction(db *database) *transaction {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &transaction{db: db, ctx: ctx, cancel: cancel}
+}
+
+var errClosed = errors.New("cypher/neoquery: the transaction has been closed")
+
+func (tx *transaction) Run(statement string, params map[string]interface{}) cypher.Result {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	qr, err := tx.run(statement, params)
+	if err != nil {
+		return &result{err: err}
+	}
+	return qr.result(0)
+}
+
+func (tx *transaction) RunMany(cypherOrParams ...interface{}) cypher.Response {
+	return tx.runMany(cypherOrParams...)
+}
+
+func (tx *transaction) runMany(cypherOrParams ...interface{}) *response {
+	statements, err := splitStatements(cypherOrParams)
+	if err != nil {
+		return &response{err: err}
+	}
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	res := &response{results: make([]*result, 0, len(statements))}
+	for i, s := range statements {
+		qr, err := tx.run(s.Cypher, s.Params)
+		if err != nil {
+			res.err = errors.WithMessagef(err, "statement %v failed", i)
+			return res
+		}
+		res.results = append(res.results, qr.result(i))
+		res.notifications = append(res.notifications, qr.Notifications...)
+	}
+	return res
+}
+
+// Run a statement in the transaction, opening it if this is the first statement.
+// The server rolls back the transaction when a statement fails, but errors before the statement reached it,
+// such as parameters which cannot be encoded or a lost connection, leave the transaction open.
+func (tx *transaction) run(statement string, params map[string]interface{}) (*queryResponse, error) {
+	if tx.closed {
+		return nil, errClosed
+	}
+	relPath := "/tx"
+	if tx.id != "" {
+		relPath += "/" + tx.id
+	}
+	qr, affinity, err := tx.db.query(tx.ctx, "POST", relPath, tx.affinity, statement, params)
+	if err != nil {
+		if qr != nil && qr.endsTransaction() {
+			tx.close()
+		}
+		return nil, err
+	}
+	if qr.Transaction != nil && tx.id == "" {
+		tx.id = qr.Transaction.ID
+	}
+	if affinity != "" {
+		tx.affinity = affinity
+	}
+	return qr, nil
+}
+
+func (tx *transaction) Commit() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.closed {
+		return errClosed
+	}
+	defer tx.close()
+	if tx.id == "" {
+		// Nothing was ever run, so the transaction was never opened on the server.
+		return nil
+	}
+	qr, _, err := tx.db.query(tx.ctx, "POST", "/tx/"+tx.id+"/commit", tx.affinity, "", nil)
+	if err != nil {
+		return errMsg(err, "error during commit request")
+	}
+	tx.bookmarks = qr.Bookmarks
+	return nil
+}
+
+func (tx *transaction) Rollback() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.closed {
+		// Either it was already rolled back, or the server rolled it back after an error.
+		return nil
+	}
+	defer tx.close()
+	if tx.id == "" {
+		return nil
+	}
+	if _, _, err := tx.db.query(context.Background(), "DELETE", "/tx/"+tx.id, tx.affinity, "", nil); err != nil {
+		return errMsg(err, "error during rollback request")
+	}
+	return nil
+}
+
+// Roll back a transaction which was left open when the database is closed,
+// cancelling any request which is still being sent.
+func (tx *transaction) abandon() error {
+	tx.cancel()
+	return tx.Rollback()
+}
+
+func (tx *transaction) close() {
+	tx.closed = true
+	tx.db.txs.Untrack(tx)
+}
+
+// Split the arguments of RunMany into statements and their parameters.
+func splitStatements(cypherOrParams []interface{}) ([]cypher.Statement, error) {
+	statements := make([]cypher.Statement, 0, 10)
+	for _, val := range cypherOrParams {
+		switch v := val.(type) {
+		case string:
+			statements = append(statements, cypher.Statement{Cypher: v})
+		case map[string]interface{}:
+			if len(statements) == 0 {
+				continue
+			}
+			statements[len(statements)-1].Params = v
+		default:
+			return nil, errors.New(
+				"RunMany() accepts only string cypher statements, or map[string]interface{} parameter declarations")
+		}
+	}
+	return statements, nil
+}
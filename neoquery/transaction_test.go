@@ -0,0 +1,114 @@
+package neoquery
+
+import (
+	"github.com/tjbrockmeyer/cypher"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestTransaction(t *testing.T) {
+	s := newFakeServer(t)
+	db := s.connect(t)
+	tx, err := db.TX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = tx.Run("RETURN 1", nil).Consume(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if bookmarks := tx.(*transaction).bookmarks; !reflect.DeepEqual(bookmarks, []string{"bookmark:1"}) {
+		t.Errorf("bookmarks are %v", bookmarks)
+	}
+	if n := s.opened(); n != 1 {
+		t.Errorf("opened %d transactions, expected 1", n)
+	}
+	if err = tx.Commit(); err != errClosed {
+		t.Errorf("committing twice returned %v", err)
+	}
+	if db.OpenTransactions() != 0 {
+		t.Errorf("%d transactions are still open", db.OpenTransactions())
+	}
+}
+
+func TestTransactionStatementError(t *testing.T) {
+	s := newFakeServer(t)
+	db := s.connect(t)
+	tx, err := db.TX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Run("RETURN 1", nil).Consume(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Run("FAIL", nil).Consume(); err == nil {
+		t.Fatal("a failed statement did not return an error")
+	}
+	// The server rolled back the transaction.
+	if _, err = tx.Run("RETURN 1", nil).Consume(); err != errClosed {
+		t.Errorf("running after a failed statement returned %v", err)
+	}
+	if err = tx.Rollback(); err != nil {
+		t.Error(err)
+	}
+	if n := s.rollbacks(); n != 0 {
+		t.Errorf("rolled back %d transactions which the server had already rolled back", n)
+	}
+	if db.OpenTransactions() != 0 {
+		t.Errorf("%d transactions are still open", db.OpenTransactions())
+	}
+}
+
+func TestTransactionClientError(t *testing.T) {
+	s := newFakeServer(t)
+	db := s.connect(t)
+	tx, err := db.TX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tx.Run("RETURN 1", nil).Consume(); err != nil {
+		t.Fatal(err)
+	}
+	// The parameter cannot be encoded, so the statement is never sent.
+	if _, err = tx.Run("ECHO", map[string]interface{}{"x": uint64(math.MaxUint64)}).Consume(); err == nil {
+		t.Fatal("an unencodable parameter did not return an error")
+	}
+	if _, err = tx.Run("RETURN 1", nil).Consume(); err != nil {
+		t.Errorf("running after a client error returned %v", err)
+	}
+	if db.OpenTransactions() != 1 {
+		t.Errorf("%d transactions are open, expected 1", db.OpenTransactions())
+	}
+	if err = tx.Rollback(); err != nil {
+		t.Error(err)
+	}
+	if n := s.rollbacks(); n != 1 {
+		t.Errorf("rolled back %d transactions, expected 1", n)
+	}
+}
+
+func TestTXJob(t *testing.T) {
+	s := newFakeServer(t)
+	db := s.connect(t)
+	n, err := cypher.TXJobT(db, func(tx cypher.Transaction) (int64, error) {
+		row, err := cypher.Single(tx.Run("RETURN 1", nil))
+		if err != nil {
+			return 0, err
+		}
+		return row.GetAt(0).(int64), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("returned %v, expected 1", n)
+	}
+	if db.OpenTransactions() != 0 {
+		t.Errorf("%d transactions are still open", db.OpenTransactions())
+	}
+}
@@ -0,0 +1,13 @@
+package neoquery
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher/internal/httpapi"
+)
+
+const logger = httpapi.Logger("cypher/neoquery")
+
+var (
+	errMsg   = errors.WithMessage
+	debugLog = logger.Debugf
+)
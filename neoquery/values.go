@@ -0,0 +1,326 @@
+package neoquery
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"github.com/tjbrockmeyer/cypher/internal/httpapi"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A value in the typed json format of the query api.
+type typedValue struct {
+	Type  string          `json:"$type"`
+	Value json.RawMessage `json:"_value"`
+}
+
+type typedParam struct {
+	Type  string      `json:"$type"`
+	Value interface{} `json:"_value"`
+}
+
+type node struct {
+	ElementID  string                     `json:"_element_id"`
+	Labels     []string                   `json:"_labels"`
+	Properties map[string]json.RawMessage `json:"_properties"`
+}
+
+type relationship struct {
+	ElementID      string                     `json:"_element_id"`
+	StartElementID string                     `json:"_start_node_element_id"`
+	EndElementID   string                     `json:"_end_node_element_id"`
+	Type           string                     `json:"_type"`
+	Properties     map[string]json.RawMessage `json:"_properties"`
+}
+
+const localDateTimeLayout = "2006-01-02T15:04:05.999999999"
+
+// Decode a typed json value of the query api into its Go representation:
+//
+//	Null: nil
+//	Boolean: bool
+//	Integer: int64
+//	Float: float64
+//	String: string
+//	Base64: []byte
+//	Date, LocalDateTime, DateTime, OffsetDateTime: time.Time, in UTC unless the value has a zone or offset
+//	Time, LocalTime, Duration: string
+//	Point: cypher.Point
+//	List: []interface{}
+//	Map: map[string]interface{}
+//	Node, Relationship, Path: cypher.Node, cypher.Relationship, cypher.Path
+//
+// Untyped json, as sent by servers which do not support the typed format, is decoded as by encoding/json.
+func decodeValue(raw json.RawMessage) (interface{}, error) {
+	trimmed := bytes.TrimSpace(raw)
+	var tv typedValue
+	if len(trimmed) == 0 || trimmed[0] != '{' || json.Unmarshal(trimmed, &tv) != nil || tv.Type == "" {
+		var v interface{}
+		err := json.Unmarshal(trimmed, &v)
+		return v, err
+	}
+	switch tv.Type {
+	case "Null":
+		return nil, nil
+	case "Boolean":
+		var b bool
+		err := json.Unmarshal(tv.Value, &b)
+		return b, err
+	case "Integer":
+		return strconv.ParseInt(unquote(tv.Value), 10, 64)
+	case "Float":
+		return strconv.ParseFloat(unquote(tv.Value), 64)
+	case "String", "Time", "LocalTime", "Duration":
+		var s string
+		err := json.Unmarshal(tv.Value, &s)
+		return s, err
+	case "Base64":
+		var b []byte
+		err := json.Unmarshal(tv.Value, &b)
+		return b, err
+	case "Date":
+		return time.Parse("2006-01-02", unquote(tv.Value))
+	case "LocalDateTime":
+		return time.Parse(localDateTimeLayout, unquote(tv.Value))
+	case "DateTime", "OffsetDateTime":
+		return httpapi.ParseDateTime(unquote(tv.Value))
+	case "Point":
+		return cypher.ParsePoint(unquote(tv.Value))
+	case "List":
+		var items []json.RawMessage
+		if err := json.Unmarshal(tv.Value, &items); err != nil {
+			return nil, err
+		}
+		return decodeList(items)
+	case "Map":
+		var entries map[string]json.RawMessage
+		if err := json.Unmarshal(tv.Value, &entries); err != nil {
+			return nil, err
+		}
+		return decodeMap(entries)
+	case "Node":
+		var n node
+		if err := json.Unmarshal(tv.Value, &n); err != nil {
+			return nil, err
+		}
+		return decodeNode(n)
+	case "Relationship":
+		var r relationship
+		if err := json.Unmarshal(tv.Value, &r); err != nil {
+			return nil, err
+		}
+		return decodeRelationship(r)
+	case "Path":
+		var items []json.RawMessage
+		if err := json.Unmarshal(tv.Value, &items); err != nil {
+			return nil, err
+		}
+		return decodePath(items)
+	default:
+		var v interface{}
+		err := json.Unmarshal(tv.Value, &v)
+		return v, errors.WithMessage(err, "failed to decode value of type "+tv.Type)
+	}
+}
+
+func unquote(raw json.RawMessage) string {
+	return strings.Trim(string(bytes.TrimSpace(raw)), `"`)
+}
+
+func decodeList(items []json.RawMessage) ([]interface{}, error) {
+	list := make([]interface{}, len(items))
+	for i, item := range items {
+		v, err := decodeValue(item)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to decode list item %v", i)
+		}
+		list[i] = v
+	}
+	return list, nil
+}
+
+func decodeMap(entries map[string]json.RawMessage) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, len(entries))
+	for key, entry := range entries {
+		v, err := decodeValue(entry)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to decode map entry "+key)
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+func decodeNode(n node) (cypher.Node, error) {
+	props, err := decodeMap(n.Properties)
+	return cypher.Node{ElementID: n.ElementID, Labels: n.Labels, Properties: props}, err
+}
+
+func decodeRelationship(r relationship) (cypher.Relationship, error) {
+	props, err := decodeMap(r.Properties)
+	return cypher.Relationship{
+		ElementID:      r.ElementID,
+		Type:           r.Type,
+		StartElementID: r.StartElementID,
+		EndElementID:   r.EndElementID,
+		Properties:     props,
+	}, err
+}
+
+func decodePath(items []json.RawMessage) (cypher.Path, error) {
+	var p cypher.Path
+	for i, item := range items {
+		v, err := decodeValue(item)
+		if err != nil {
+			return p, errors.WithMessagef(err, "failed to decode path element %v", i)
+		}
+		switch e := v.(type) {
+		case cypher.Node:
+			p.Nodes = append(p.Nodes, e)
+		case cypher.Relationship:
+			p.Relationships = append(p.Relationships, e)
+		default:
+			return p, errors.Errorf("path element %v is neither a node nor a relationship", i)
+		}
+	}
+	return p, nil
+}
+
+// Encode parameters in the typed json format of the query api.
+func encodeParams(params map[string]interface{}) (map[string]interface{}, error) {
+	typed := make(map[string]interface{}, len(params))
+	for name, value := range params {
+		v, err := encodeValue(value)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to encode parameter "+name)
+		}
+		typed[name] = v
+	}
+	return typed, nil
+}
+
+// Encode a Go value in the typed json format of the query api.
+// Types without a direct representation are encoded as the value they marshal to with encoding/json.
+// Valuers are encoded as the values they convert to.
+func encodeValue(value interface{}) (typedParam, error) {
+	if _, ok := value.(cypher.Valuer); ok {
+		converted, err := cypher.ConvertValue(value)
+		if err != nil {
+			return typedParam{}, err
+		}
+		return encodeValue(converted)
+	}
+	switch v := value.(type) {
+	case nil:
+		return typedParam{"Null", nil}, nil
+	case bool:
+		return typedParam{"Boolean", v}, nil
+	case string:
+		return typedParam{"String", v}, nil
+	case []byte:
+		return typedParam{"Base64", base64.StdEncoding.EncodeToString(v)}, nil
+	case time.Time:
+		return typedParam{"OffsetDateTime", v.Format(time.RFC3339Nano)}, nil
+	case time.Duration:
+		return typedParam{"Duration", "PT" + strconv.FormatFloat(v.Seconds(), 'f', -1, 64) + "S"}, nil
+	case cypher.Point:
+		return typedParam{"Point", v.String()}, nil
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return typedParam{"Integer", v.String()}, nil
+		}
+		return typedParam{"Float", v.String()}, nil
+	case json.Marshaler:
+		return encodeJSON(v)
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return typedParam{"Boolean", rv.Bool()}, nil
+	case reflect.String:
+		return typedParam{"String", rv.String()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return typedParam{"Integer", strconv.FormatInt(rv.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return typedParam{}, errors.Errorf("integer %v overflows int64", rv.Uint())
+		}
+		return typedParam{"Integer", strconv.FormatUint(rv.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return typedParam{"Float", formatFloat(rv.Float())}, nil
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return typedParam{"Null", nil}, nil
+		}
+		return encodeValue(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return typedParam{"Null", nil}, nil
+		}
+		list := make([]typedParam, rv.Len())
+		for i := range list {
+			item, err := encodeValue(rv.Index(i).Interface())
+			if err != nil {
+				return typedParam{}, errors.WithMessagef(err, "failed to encode list item %v", i)
+			}
+			list[i] = item
+		}
+		return typedParam{"List", list}, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return encodeJSON(value)
+		}
+		if rv.IsNil() {
+			return typedParam{"Null", nil}, nil
+		}
+		m := make(map[string]typedParam, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			entry, err := encodeValue(iter.Value().Interface())
+			if err != nil {
+				return typedParam{}, errors.WithMessage(err, "failed to encode map entry "+key)
+			}
+			m[key] = entry
+		}
+		return typedParam{"Map", m}, nil
+	default:
+		return encodeJSON(value)
+	}
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Encode a value as the json it marshals to, such as an object for a struct.
+func encodeJSON(value interface{}) (typedParam, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return typedParam{}, errors.WithMessage(err, "failed to marshal value into json")
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err = dec.Decode(&v); err != nil {
+		return typedParam{}, errors.WithMessage(err, "failed to unmarshal value from json")
+	}
+	if s, ok := v.(string); ok {
+		return typedParam{"String", s}, nil
+	}
+	return encodeValue(v)
+}
@@ -0,0 +1,87 @@
+package neoquery
+
+import (
+	"encoding/json"
+	"github.com/tjbrockmeyer/cypher"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecodeValue(t *testing.T) {
+	node := cypher.Node{ElementID: "4:a:1", Labels: []string{"Person"}, Properties: map[string]interface{}{"age": int64(42)}}
+	rel := cypher.Relationship{ElementID: "5:a:2", Type: "KNOWS", StartElementID: "4:a:1", EndElementID: "4:a:1",
+		Properties: map[string]interface{}{}}
+	nodeJSON := `{"$type":"Node","_value":{"_element_id":"4:a:1","_labels":["Person"],` +
+		`"_properties":{"age":{"$type":"Integer","_value":"42"}}}}`
+	relJSON := `{"$type":"Relationship","_value":{"_element_id":"5:a:2","_start_node_element_id":"4:a:1",` +
+		`"_end_node_element_id":"4:a:1","_type":"KNOWS","_properties":{}}}`
+	for _, test := range []struct {
+		raw      string
+		expected interface{}
+	}{
+		{`{"$type":"Null","_value":null}`, nil},
+		{`{"$type":"Boolean","_value":true}`, true},
+		{`{"$type":"Integer","_value":"9007199254740993"}`, int64(9007199254740993)},
+		{`{"$type":"Float","_value":"2.0"}`, 2.0},
+		{`{"$type":"String","_value":"s"}`, "s"},
+		{`{"$type":"Base64","_value":"YWJj"}`, []byte("abc")},
+		{`{"$type":"Duration","_value":"P1DT2H"}`, "P1DT2H"},
+		{`{"$type":"Date","_value":"2024-02-29"}`, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{`{"$type":"LocalDateTime","_value":"2024-02-29T13:30:00.5"}`, time.Date(2024, 2, 29, 13, 30, 0, 5e8, time.UTC)},
+		{`{"$type":"DateTime","_value":"2024-02-29T13:30:00Z"}`, time.Date(2024, 2, 29, 13, 30, 0, 0, time.UTC)},
+		{`{"$type":"Point","_value":"SRID=4326;POINT (12.5 56.2)"}`, cypher.Point{SRID: 4326, X: 12.5, Y: 56.2}},
+		{`{"$type":"List","_value":[{"$type":"Integer","_value":"1"},{"$type":"String","_value":"two"}]}`,
+			[]interface{}{int64(1), "two"}},
+		{`{"$type":"Map","_value":{"a":{"$type":"Integer","_value":"1"}}}`, map[string]interface{}{"a": int64(1)}},
+		{nodeJSON, node},
+		{relJSON, rel},
+		{`{"$type":"Path","_value":[` + nodeJSON + `,` + relJSON + `,` + nodeJSON + `]}`,
+			cypher.Path{Nodes: []cypher.Node{node, node}, Relationships: []cypher.Relationship{rel}}},
+		// Untyped json, from servers which do not support the typed format.
+		{`{"a":1}`, map[string]interface{}{"a": 1.0}},
+	} {
+		actual, err := decodeValue(json.RawMessage(test.raw))
+		if err != nil {
+			t.Errorf("%s: %v", test.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s decoded to %#v, expected %#v", test.raw, actual, test.expected)
+		}
+	}
+}
+
+func TestEncodeValue(t *testing.T) {
+	type point struct {
+		X int `json:"x"`
+	}
+	for _, test := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, `{"$type":"Null","_value":null}`},
+		{int32(7), `{"$type":"Integer","_value":"7"}`},
+		{uint8(7), `{"$type":"Integer","_value":"7"}`},
+		{2.0, `{"$type":"Float","_value":"2"}`},
+		{json.Number("1.5"), `{"$type":"Float","_value":"1.5"}`},
+		{[]byte("abc"), `{"$type":"Base64","_value":"YWJj"}`},
+		{90 * time.Second, `{"$type":"Duration","_value":"PT90S"}`},
+		{time.Date(2024, 2, 29, 13, 30, 0, 0, time.UTC), `{"$type":"OffsetDateTime","_value":"2024-02-29T13:30:00Z"}`},
+		{[]string{"a"}, `{"$type":"List","_value":[{"$type":"String","_value":"a"}]}`},
+		{point{X: 1}, `{"$type":"Map","_value":{"x":{"$type":"Integer","_value":"1"}}}`},
+	} {
+		typed, err := encodeValue(test.value)
+		if err != nil {
+			t.Errorf("%#v: %v", test.value, err)
+			continue
+		}
+		b, _ := json.Marshal(typed)
+		if string(b) != test.expected {
+			t.Errorf("%#v encoded to %s, expected %s", test.value, b, test.expected)
+		}
+	}
+	if _, err := encodeValue(uint64(1 << 63)); err == nil {
+		t.Error("an integer which overflows int64 was encoded")
+	}
+}
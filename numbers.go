@@ -0,0 +1,187 @@
+package cypher
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+)
+
+// How the numbers in the values of rows are represented, for drivers which let it be chosen.
+type NumberMode int
+
+const (
+	// Every number is a float64, as decoded by encoding/json. Integers beyond 2^53 lose precision.
+	NumberFloat64 NumberMode = iota
+	// Integers are int64 and other numbers are float64.
+	NumberInt64
+	// Every number is a json.Number, leaving its conversion to the caller.
+	NumberJSON
+)
+
+// NumberRow is implemented by rows whose numbers are not all float64.
+// UnmarshalRow and UnmarshalRows represent the numbers of interface{} fields in the same way.
+type NumberRow interface {
+	NumberMode() NumberMode
+}
+
+// Get the number mode of a row.
+func NumberModeOf(row Row) NumberMode {
+	if r, ok := row.(NumberRow); ok {
+		return r.NumberMode()
+	}
+	return NumberFloat64
+}
+
+// Convert the json.Numbers of a value, including those within lists and maps, into the given mode.
+// Lists and maps are converted in place.
+func ConvertNumbers(value interface{}, mode NumberMode) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		switch mode {
+		case NumberInt64:
+			if i, err := v.Int64(); err == nil {
+				return i
+			}
+			f, _ := v.Float64()
+			return f
+		case NumberFloat64:
+			f, _ := v.Float64()
+			return f
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = ConvertNumbers(item, mode)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = ConvertNumbers(item, mode)
+		}
+	}
+	return value
+}
+
+// Get a number of any representation as an int64.
+// Returns false if the value is not a number or is not a whole number.
+func AsInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, false
+		}
+		return int64(v), true
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// Convert the json.Numbers held by the interface{} values within v, such as the fields of a struct.
+func convertNumbersIn(v reflect.Value, mode NumberMode) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			convertNumbersIn(v.Elem(), mode)
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if v.NumMethod() == 0 && v.CanSet() {
+			v.Set(reflect.ValueOf(ConvertNumbers(v.Interface(), mode)))
+			return
+		}
+		convertNumbersIn(v.Elem(), mode)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				convertNumbersIn(v.Field(i), mode)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			convertNumbersIn(v.Index(i), mode)
+		}
+	case reflect.Map:
+		elem := v.Type().Elem()
+		if elem.Kind() != reflect.Interface || elem.NumMethod() != 0 {
+			if elem.Kind() == reflect.Ptr {
+				iter := v.MapRange()
+				for iter.Next() {
+					convertNumbersIn(iter.Value(), mode)
+				}
+			}
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			converted := reflect.Zero(elem)
+			if c := ConvertNumbers(iter.Value().Interface(), mode); c != nil {
+				converted = reflect.ValueOf(c)
+			}
+			v.SetMapIndex(iter.Key(), converted)
+		}
+	}
+}
+
+// A float64 which is marshaled with a fraction or exponent even when it is whole,
+// so that it is not mistaken for an integer when unmarshaled with NumberInt64.
+type fractionFloat float64
+
+func (f fractionFloat) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(float64(f))
+	if err != nil || bytes.ContainsAny(b, ".eE") {
+		return b, err
+	}
+	return append(b, '.', '0'), nil
+}
+
+// Copy a value, replacing its float64s with fractionFloats, including those within lists, maps and graph values.
+func fractionFloats(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		return fractionFloat(v)
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = fractionFloats(item)
+		}
+		return list
+	case map[string]interface{}:
+		return fractionFloatsIn(v)
+	case Node:
+		v.Properties = fractionFloatsIn(v.Properties)
+		return v
+	case Relationship:
+		v.Properties = fractionFloatsIn(v.Properties)
+		return v
+	case Path:
+		nodes := make([]Node, len(v.Nodes))
+		for i, n := range v.Nodes {
+			nodes[i] = fractionFloats(n).(Node)
+		}
+		rels := make([]Relationship, len(v.Relationships))
+		for i, r := range v.Relationships {
+			rels[i] = fractionFloats(r).(Relationship)
+		}
+		return Path{Nodes: nodes, Relationships: rels}
+	}
+	return value
+}
+
+func fractionFloatsIn(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(m))
+	for key, item := range m {
+		copied[key] = fractionFloats(item)
+	}
+	return copied
+}
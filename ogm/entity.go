@@ -0,0 +1,205 @@
+package ogm
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The mapping of a struct type onto nodes.
+type entity struct {
+	typ    reflect.Type
+	label  string
+	id     *property
+	fields []*property
+	rels   []*relation
+}
+
+// A struct field mapped onto a node property.
+type property struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var entities sync.Map
+
+// Get the mapping of the struct type of v, which must be a struct or a pointer to one.
+func entityOf(v interface{}) (*entity, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.Errorf("cypher/ogm: expected a struct or pointer to a struct, got %T", v)
+	}
+	return entityOfType(t)
+}
+
+func entityOfType(t reflect.Type) (*entity, error) {
+	if e, ok := entities.Load(t); ok {
+		return e.(*entity), nil
+	}
+	e := &entity{typ: t, label: t.Name()}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, hasTag := f.Tag.Lookup("cypher")
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseTag(tag)
+		if f.Name == "_" {
+			if label, ok := opts["label"]; ok {
+				e.label = label
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if relType, ok := opts["rel"]; ok {
+			rel, err := newRelation(f, relType, opts["dir"])
+			if err != nil {
+				return nil, errors.WithMessage(err, "cypher/ogm: invalid relationship field "+t.String()+"."+f.Name)
+			}
+			e.rels = append(e.rels, rel)
+			continue
+		}
+		if !hasTag || name == "" {
+			name = f.Name
+		}
+		p := &property{name: name, index: f.Index}
+		_, p.omitEmpty = opts["omitempty"]
+		if _, ok := opts["id"]; ok {
+			if e.id != nil {
+				return nil, errors.New("cypher/ogm: more than one id property declared on " + t.String())
+			}
+			e.id = p
+		}
+		e.fields = append(e.fields, p)
+	}
+	if e.id == nil {
+		return nil, errors.New("cypher/ogm: no id property declared on " + t.String() + " - tag a field with `cypher:\"name,id\"`")
+	}
+	if e.label == "" {
+		return nil, errors.New("cypher/ogm: no label declared on " + t.String())
+	}
+	actual, _ := entities.LoadOrStore(t, e)
+	return actual.(*entity), nil
+}
+
+// Parse a tag of the form name,option,key=value into the name and its options.
+func parseTag(tag string) (string, map[string]string) {
+	parts := strings.Split(tag, ",")
+	opts := make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		if kv := strings.SplitN(part, "=", 2); len(kv) == 2 {
+			opts[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		} else {
+			opts[strings.TrimSpace(part)] = ""
+		}
+	}
+	name := strings.TrimSpace(parts[0])
+	if kv := strings.SplitN(name, "=", 2); len(kv) == 2 {
+		opts[kv[0]] = kv[1]
+		name = ""
+	}
+	return name, opts
+}
+
+func (e *entity) property(name string) *property {
+	for _, p := range e.fields {
+		if p.name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Get the properties of the struct value v as parameters.
+func (e *entity) properties(v reflect.Value) map[string]interface{} {
+	return properties(e.fields, v)
+}
+
+func properties(fields []*property, v reflect.Value) map[string]interface{} {
+	props := make(map[string]interface{}, len(fields))
+	for _, p := range fields {
+		f := v.FieldByIndex(p.index)
+		if p.omitEmpty && f.IsZero() {
+			continue
+		}
+		props[p.name] = f.Interface()
+	}
+	return props
+}
+
+// Set the fields of the struct value v from node properties.
+// Values are converted through json, so any field type which json can decode is supported.
+func (e *entity) assign(v reflect.Value, props map[string]interface{}) error {
+	return assign(e.fields, v, props)
+}
+
+func assign(fields []*property, v reflect.Value, props map[string]interface{}) error {
+	for _, p := range fields {
+		value, ok := props[p.name]
+		f := v.FieldByIndex(p.index)
+		if !ok || value == nil {
+			f.Set(reflect.Zero(f.Type()))
+			continue
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return errors.WithMessage(err, "cypher/ogm: failed to marshal property "+p.name)
+		}
+		if err = json.Unmarshal(b, f.Addr().Interface()); err != nil {
+			return errors.WithMessage(err, "cypher/ogm: failed to unmarshal property "+p.name)
+		}
+	}
+	return nil
+}
+
+func quote(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+// Set the fields of the struct value v from a loaded node projection, including any loaded relationships.
+func (e *entity) assignNode(v reflect.Value, node map[string]interface{}) error {
+	if err := e.assign(v, node); err != nil {
+		return err
+	}
+	for _, rel := range e.rels {
+		if loaded, ok := node[rel.key()].([]interface{}); ok {
+			if err := rel.assign(v, loaded); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Build a map projection of the node variable holding its properties and,
+// up to the given depth, the relationships and target nodes of each relationship field.
+func (e *entity) projection(variable string, depth int, counter *int) (string, error) {
+	if depth <= 0 || len(e.rels) == 0 {
+		return variable + " {.*}", nil
+	}
+	parts := []string{".*"}
+	for _, rel := range e.rels {
+		te, err := entityOfType(rel.nodeType)
+		if err != nil {
+			return "", err
+		}
+		*counter++
+		r, m := "r"+strconv.Itoa(*counter), "m"+strconv.Itoa(*counter)
+		nested, err := te.projection(m, depth-1, counter)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, quote(rel.key())+": ["+rel.pattern("("+variable+")", r, "("+m+":"+quote(te.label)+")")+
+			" | {r: properties("+r+"), n: "+nested+"}]")
+	}
+	return variable + " {" + strings.Join(parts, ", ") + "}", nil
+}
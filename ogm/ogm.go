@@ -0,0 +1,263 @@
+// Package ogm maps go structs onto nodes, generating the cypher to save, load, find and delete them.
+//
+// A struct declares its label with a blank field, and its id property with the id option:
+//
+//	type Person struct {
+//		_    struct{} `cypher:"label=Person"`
+//		ID   string   `cypher:"uuid,id"`
+//		Name string   `cypher:"name"`
+//		Age  int      `cypher:"age,omitempty"`
+//	}
+//
+// Exported fields without a tag are mapped to a property of the same name, and fields tagged "-" are ignored.
+// The label defaults to the name of the struct type.
+//
+// Fields tagged with rel=TYPE map onto relationships to other mapped structs; see relation for the details.
+// Relationships are loaded eagerly in the same query as their node, to the depth configured on the Mapper.
+// Saving a node saves its targets and makes its relationships match the struct: relationships to targets
+// no longer held by the field are deleted. A nil field, or a struct field whose target has an empty id,
+// is treated as not loaded, leaving its relationships untouched.
+package ogm
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Returned when loading a node which does not exist.
+var ErrNotFound = errors.New("cypher/ogm: node not found")
+
+type Mapper struct {
+	db    cypher.DB
+	depth int
+}
+
+// Create a mapper which loads relationships to a depth of 1.
+func New(db cypher.DB) *Mapper {
+	return &Mapper{db: db, depth: 1}
+}
+
+// Returns a copy of the mapper which loads relationships to the given depth.
+// A depth of 0 loads only the properties of nodes.
+func (m *Mapper) WithDepth(depth int) *Mapper {
+	return &Mapper{db: m.db, depth: depth}
+}
+
+// Save the struct pointed to by v as a node, merging on its id property and replacing all other properties.
+// The targets of its relationship fields are saved along with it, all in a single transaction.
+func (m *Mapper) Save(v interface{}) error {
+	e, rv, err := pointerEntity(v)
+	if err != nil {
+		return err
+	}
+	_, err = m.db.TXJob(func(tx cypher.Transaction) (interface{}, error) {
+		s := &saver{runner: tx, saved: make(map[string]bool)}
+		return nil, s.save(e, rv)
+	})
+	return err
+}
+
+type saver struct {
+	runner cypher.Runner
+	// The nodes which have already been saved, to stop at cycles in the struct graph.
+	saved map[string]bool
+}
+
+func (s *saver) save(e *entity, rv reflect.Value) error {
+	id := rv.FieldByIndex(e.id.index)
+	if id.IsZero() {
+		return errors.New("cypher/ogm: cannot save " + e.typ.String() + " with an empty id")
+	}
+	key := e.label + ":" + fmt.Sprint(id.Interface())
+	if s.saved[key] {
+		return nil
+	}
+	s.saved[key] = true
+	match := "(a:" + quote(e.label) + " {" + quote(e.id.name) + ": $id})"
+	_, err := s.runner.Run("MERGE "+match+" SET a = $props", map[string]interface{}{
+		"id":    id.Interface(),
+		"props": e.properties(rv),
+	}).Consume()
+	if err != nil {
+		return errors.WithMessage(err, "cypher/ogm: failed to save "+e.typ.String())
+	}
+
+	for _, rel := range e.rels {
+		te, err := entityOfType(rel.nodeType)
+		if err != nil {
+			return err
+		}
+		targets, loaded := rel.targets(rv, te)
+		if !loaded {
+			continue
+		}
+		ids := make([]interface{}, 0, len(targets))
+		for _, t := range targets {
+			if err = s.save(te, t.node); err != nil {
+				return err
+			}
+			targetID := t.node.FieldByIndex(te.id.index).Interface()
+			ids = append(ids, targetID)
+			_, err = s.runner.Run("MATCH "+match+" MATCH (b:"+quote(te.label)+" {"+quote(te.id.name)+": $target}) MERGE "+
+				rel.pattern("(a)", "r", "(b)")+" SET r = $props", map[string]interface{}{
+				"id":     id.Interface(),
+				"target": targetID,
+				"props":  t.props,
+			}).Consume()
+			if err != nil {
+				return errors.WithMessage(err, "cypher/ogm: failed to save relationship "+e.typ.String()+"."+rel.name)
+			}
+		}
+		_, err = s.runner.Run("MATCH "+rel.pattern(match, "r", "(b:"+quote(te.label)+")")+
+			" WHERE NOT b."+quote(te.id.name)+" IN $targets DELETE r", map[string]interface{}{
+			"id":      id.Interface(),
+			"targets": ids,
+		}).Consume()
+		if err != nil {
+			return errors.WithMessage(err, "cypher/ogm: failed to delete relationships of "+e.typ.String()+"."+rel.name)
+		}
+	}
+	return nil
+}
+
+// Load the node with the id of the struct pointed to by v, replacing the fields of the struct.
+// Returns ErrNotFound if there is no such node.
+func (m *Mapper) Load(v interface{}) error {
+	e, rv, err := pointerEntity(v)
+	if err != nil {
+		return err
+	}
+	projection, err := e.projection("n", m.depth, new(int))
+	if err != nil {
+		return err
+	}
+	row, err := cypher.Single(m.db.Run("MATCH (n:"+quote(e.label)+" {"+quote(e.id.name)+": $id}) RETURN "+projection+" AS n LIMIT 1",
+		map[string]interface{}{"id": rv.FieldByIndex(e.id.index).Interface()}))
+	if err != nil {
+		return errors.WithMessage(err, "cypher/ogm: failed to load "+e.typ.String())
+	}
+	if row == nil {
+		return ErrNotFound
+	}
+	props, _ := row.GetAt(0).(map[string]interface{})
+	return e.assignNode(rv, props)
+}
+
+// Delete the node with the id of the struct pointed to by v, along with its relationships.
+func (m *Mapper) Delete(v interface{}) error {
+	e, rv, err := pointerEntity(v)
+	if err != nil {
+		return err
+	}
+	_, err = m.db.TXJob(func(tx cypher.Transaction) (interface{}, error) {
+		_, err := tx.Run("MATCH (n:"+quote(e.label)+" {"+quote(e.id.name)+": $id}) DETACH DELETE n",
+			map[string]interface{}{"id": rv.FieldByIndex(e.id.index).Interface()}).Consume()
+		return nil, err
+	})
+	return errors.WithMessage(err, "cypher/ogm: failed to delete "+e.typ.String())
+}
+
+// Find all nodes of type T whose properties equal the given filters.
+// T must be a mapped struct type or a pointer to one.
+func FindBy[T any](m *Mapper, filters map[string]interface{}) ([]T, error) {
+	e, err := entityOfParam[T]()
+	if err != nil {
+		return nil, err
+	}
+	where, params, err := e.where(filters)
+	if err != nil {
+		return nil, err
+	}
+	projection, err := e.projection("n", m.depth, new(int))
+	if err != nil {
+		return nil, err
+	}
+	result := m.db.Run("MATCH (n:"+quote(e.label)+")"+where+" RETURN "+projection+" AS n", params)
+	values := make([]T, 0, 30)
+	for result.NextRow() {
+		var value T
+		target := reflect.ValueOf(&value).Elem()
+		if target.Kind() == reflect.Ptr {
+			target.Set(reflect.New(e.typ))
+			target = target.Elem()
+		}
+		props, _ := result.GetRow().GetAt(0).(map[string]interface{})
+		if err = e.assignNode(target, props); err != nil {
+			_, _ = result.Consume()
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, errors.WithMessage(result.Err(), "cypher/ogm: failed to find "+e.typ.String())
+}
+
+// Count the nodes of type T whose properties equal the given filters.
+func Count[T any](m *Mapper, filters map[string]interface{}) (int, error) {
+	e, err := entityOfParam[T]()
+	if err != nil {
+		return 0, err
+	}
+	where, params, err := e.where(filters)
+	if err != nil {
+		return 0, err
+	}
+	row, err := cypher.Single(m.db.Run("MATCH (n:"+quote(e.label)+")"+where+" RETURN count(n) AS count", params))
+	if err != nil {
+		return 0, errors.WithMessage(err, "cypher/ogm: failed to count "+e.typ.String())
+	}
+	if row == nil {
+		return 0, nil
+	}
+	count, _ := cypher.AsInt64(row.GetAt(0))
+	return int(count), nil
+}
+
+// Build a where clause matching each of the filters by equality.
+// Filters must name mapped properties.
+func (e *entity) where(filters map[string]interface{}) (string, map[string]interface{}, error) {
+	if len(filters) == 0 {
+		return "", nil, nil
+	}
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		if e.property(name) == nil {
+			return "", nil, errors.New("cypher/ogm: " + name + " is not a property of " + e.typ.String())
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	conditions := make([]string, len(names))
+	params := make(map[string]interface{}, len(names))
+	for i, name := range names {
+		param := "p" + strconv.Itoa(i)
+		conditions[i] = "n." + quote(name) + " = $" + param
+		params[param] = filters[name]
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), params, nil
+}
+
+// Get the entity of a type parameter, which must be a struct or a pointer to a struct.
+func entityOfParam[T any]() (*entity, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		return entityOfType(t.Elem())
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, errors.Errorf("cypher/ogm: expected a struct or pointer to a struct type, got %v", t)
+	}
+	return entityOfType(t)
+}
+
+func pointerEntity(v interface{}) (*entity, reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil, rv, errors.Errorf("cypher/ogm: expected a non-nil pointer to a struct, got %T", v)
+	}
+	e, err := entityOf(v)
+	return e, rv.Elem(), err
+}
@@ -0,0 +1,223 @@
+package ogm
+
+import (
+	"github.com/pkg/errors"
+	"reflect"
+)
+
+// A struct field mapped onto the relationships of a node.
+//
+// The field may hold a single target or a slice of targets, each of which is either
+// a mapped struct, a pointer to one, or an edge struct holding the properties of the relationship:
+//
+//	type Ownership struct {
+//		Since int  `cypher:"since"`
+//		Car   *Car `cypher:",target"`
+//	}
+//
+//	type Person struct {
+//		_        struct{}    `cypher:"label=Person"`
+//		ID       string      `cypher:"id,id"`
+//		Cars     []Ownership `cypher:"rel=OWNS,dir=out"`
+//		Employer *Company    `cypher:"rel=WORKS_AT"`
+//	}
+type relation struct {
+	name  string
+	index []int
+	// The relationship type.
+	typ string
+	// One of out, in or both.
+	dir   string
+	slice bool
+	// The type of each target, which is a node or edge struct, or a pointer to one.
+	elem reflect.Type
+	edge *edge
+	// The struct type of the target node.
+	nodeType reflect.Type
+}
+
+// A struct holding the properties of a relationship alongside its target node.
+type edge struct {
+	fields []*property
+	target []int
+}
+
+func newRelation(f reflect.StructField, relType, dir string) (*relation, error) {
+	rel := &relation{name: f.Name, index: f.Index, typ: relType, dir: dir}
+	switch dir {
+	case "":
+		rel.dir = "out"
+	case "out", "in", "both":
+	default:
+		return nil, errors.New("dir must be one of out, in or both: " + dir)
+	}
+	if relType == "" {
+		return nil, errors.New("the relationship type cannot be empty")
+	}
+	rel.elem = f.Type
+	if f.Type.Kind() == reflect.Slice {
+		rel.slice = true
+		rel.elem = f.Type.Elem()
+	}
+	elem := deref(rel.elem)
+	if elem.Kind() != reflect.Struct {
+		return nil, errors.New("targets must be structs or pointers to structs")
+	}
+	rel.nodeType = elem
+	for i := 0; i < elem.NumField(); i++ {
+		ef := elem.Field(i)
+		if _, opts := parseTag(ef.Tag.Get("cypher")); hasOption(opts, "target") {
+			rel.edge, rel.nodeType = newEdge(elem), deref(ef.Type)
+			rel.edge.target = ef.Index
+			if rel.nodeType.Kind() != reflect.Struct {
+				return nil, errors.New("the target of an edge must be a struct or pointer to a struct")
+			}
+			break
+		}
+	}
+	return rel, nil
+}
+
+func newEdge(t reflect.Type) *edge {
+	e := new(edge)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, hasTag := f.Tag.Lookup("cypher")
+		name, opts := parseTag(tag)
+		if tag == "-" || f.PkgPath != "" || hasOption(opts, "target") {
+			continue
+		}
+		if !hasTag || name == "" {
+			name = f.Name
+		}
+		p := &property{name: name, index: f.Index}
+		_, p.omitEmpty = opts["omitempty"]
+		e.fields = append(e.fields, p)
+	}
+	return e
+}
+
+func hasOption(opts map[string]string, name string) bool {
+	_, ok := opts[name]
+	return ok
+}
+
+func deref(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+// The key of the relationship within a loaded node projection.
+func (rel *relation) key() string {
+	return "__rel_" + rel.name
+}
+
+// The relationship pattern between two node variables.
+func (rel *relation) pattern(from, r, to string) string {
+	switch rel.dir {
+	case "in":
+		return from + "<-[" + r + ":" + quote(rel.typ) + "]-" + to
+	case "both":
+		return from + "-[" + r + ":" + quote(rel.typ) + "]-" + to
+	default:
+		return from + "-[" + r + ":" + quote(rel.typ) + "]->" + to
+	}
+}
+
+// A target of a relationship field: the node and the properties of the relationship to it.
+type target struct {
+	node  reflect.Value
+	props map[string]interface{}
+}
+
+// Get the targets held by the relationship field of the struct value v, whose nodes are of the entity te.
+// Returns false if the field is nil, or holds a single struct whose target node is nil or has an empty id,
+// meaning that its relationships were not loaded and should be left untouched.
+func (rel *relation) targets(v reflect.Value, te *entity) ([]target, bool) {
+	f := v.FieldByIndex(rel.index)
+	var elems []reflect.Value
+	if rel.slice {
+		if f.IsNil() {
+			return nil, false
+		}
+		for i := 0; i < f.Len(); i++ {
+			elems = append(elems, f.Index(i))
+		}
+	} else {
+		if f.Kind() == reflect.Ptr && f.IsNil() {
+			return nil, false
+		}
+		elems = append(elems, f)
+	}
+	targets := make([]target, 0, len(elems))
+	for _, elem := range elems {
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				continue
+			}
+			elem = elem.Elem()
+		}
+		t := target{node: elem, props: map[string]interface{}{}}
+		if rel.edge != nil {
+			t.props = properties(rel.edge.fields, elem)
+			t.node = elem.FieldByIndex(rel.edge.target)
+			if t.node.Kind() == reflect.Ptr {
+				if t.node.IsNil() {
+					continue
+				}
+				t.node = t.node.Elem()
+			}
+		}
+		targets = append(targets, t)
+	}
+	if !rel.slice && (len(targets) == 0 || f.Kind() != reflect.Ptr && targets[0].node.FieldByIndex(te.id.index).IsZero()) {
+		return nil, false
+	}
+	return targets, true
+}
+
+// Set the relationship field of the struct value v from the loaded list of {r: properties, n: node} maps.
+func (rel *relation) assign(v reflect.Value, loaded []interface{}) error {
+	te, err := entityOfType(rel.nodeType)
+	if err != nil {
+		return err
+	}
+	f := v.FieldByIndex(rel.index)
+	elems := reflect.MakeSlice(reflect.SliceOf(rel.elem), 0, len(loaded))
+	for _, item := range loaded {
+		m, _ := item.(map[string]interface{})
+		nodeProps, _ := m["n"].(map[string]interface{})
+		relProps, _ := m["r"].(map[string]interface{})
+		node := reflect.New(rel.nodeType)
+		if err = te.assignNode(node.Elem(), nodeProps); err != nil {
+			return err
+		}
+		elem := node
+		if rel.edge != nil {
+			elem = reflect.New(deref(rel.elem))
+			if err = assign(rel.edge.fields, elem.Elem(), relProps); err != nil {
+				return err
+			}
+			tf := elem.Elem().FieldByIndex(rel.edge.target)
+			if tf.Kind() == reflect.Ptr {
+				tf.Set(node)
+			} else {
+				tf.Set(node.Elem())
+			}
+		}
+		if rel.elem.Kind() != reflect.Ptr {
+			elem = elem.Elem()
+		}
+		elems = reflect.Append(elems, elem)
+	}
+	if rel.slice {
+		f.Set(elems)
+	} else if elems.Len() > 0 {
+		f.Set(elems.Index(0))
+	} else {
+		f.Set(reflect.Zero(f.Type()))
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package paginate
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/tjbrockmeyer/cypher"
+)
+
+// A page of rows. The page is a cypher.Result, so it may be used with cypher.Collect and the unmarshal helpers.
+type Page struct {
+	columns []string
+	rows    []cypher.Row
+	stats   cypher.Stats
+	graph   *cypher.Graph
+	index   int
+	hasNext bool
+	next    string
+	total   int
+}
+
+// Returns true if there are rows after this page.
+func (p *Page) HasNext() bool {
+	return p.hasNext
+}
+
+// The cursor to request the next page with, or an empty string if this is the last page.
+func (p *Page) NextCursor() string {
+	return p.next
+}
+
+// The total number of rows across all pages, or -1 if it was not counted.
+func (p *Page) Total() int {
+	return p.total
+}
+
+// The number of rows in this page.
+func (p *Page) Len() int {
+	return len(p.rows)
+}
+
+func (p *Page) Index() int {
+	return 0
+}
+
+func (p *Page) Columns() []string {
+	return p.columns
+}
+
+func (p *Page) NextRow() bool {
+	if p.index >= len(p.rows) {
+		return false
+	}
+	p.index++
+	return true
+}
+
+func (p *Page) GetRow() cypher.Row {
+	if p.index == 0 {
+		return nil
+	}
+	return p.rows[p.index-1]
+}
+
+func (p *Page) Err() error {
+	return nil
+}
+
+func (p *Page) Consume() (cypher.Stats, error) {
+	p.index = len(p.rows)
+	return p.stats, nil
+}
+
+// The nodes and relationships found among the values of the rows of this page.
+func (p *Page) Graph() *cypher.Graph {
+	if p.graph == nil {
+		p.graph = new(cypher.Graph)
+		for _, row := range p.rows {
+			for _, value := range row.Values() {
+				p.graph.AddValue(value)
+			}
+		}
+	}
+	return p.graph
+}
+
+// A row of a page, hiding the trailing cursor columns.
+type pageRow struct {
+	row  cypher.Row
+	keys []string
+}
+
+func (r *pageRow) GetAt(i int) interface{} {
+	return r.row.GetAt(i)
+}
+
+func (r *pageRow) Get(n string) interface{} {
+	for i, key := range r.keys {
+		if key == n {
+			return r.row.GetAt(i)
+		}
+	}
+	return nil
+}
+
+func (r *pageRow) Keys() []string {
+	return r.keys
+}
+
+func (r *pageRow) Values() []interface{} {
+	return r.row.Values()[:len(r.keys)]
+}
+
+func (r *pageRow) Len() int {
+	return len(r.keys)
+}
+
+func (r *pageRow) AsMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(r.keys))
+	for i, key := range r.keys {
+		m[key] = r.row.GetAt(i)
+	}
+	return m
+}
+
+func (r *pageRow) MetaAt(i int) interface{} {
+	return r.row.MetaAt(i)
+}
+
+func (r *pageRow) NumberMode() cypher.NumberMode {
+	return cypher.NumberModeOf(r.row)
+}
+
+func (r *pageRow) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range r.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		b, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+		buf.WriteByte(':')
+		if b, err = json.Marshal(r.row.GetAt(i)); err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,256 @@
+// Package paginate pages through the results of a query by keyset (cursor) pagination.
+// Rather than skipping rows, each page continues after the sort value of the last row of the previous page,
+// so pages stay fast and stable on large graphs as rows are added and removed.
+package paginate
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"github.com/tjbrockmeyer/cypher/internal/httpapi"
+	"strconv"
+	"strings"
+)
+
+// Returned when a cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("cypher/paginate: invalid cursor")
+
+// Paginator pages through the rows matched by a base query, sorted by a property of one of its variables.
+//
+//	p := &paginate.Paginator{
+//		Base:     "MATCH (n:Person) WHERE n.age >= $age",
+//		Params:   map[string]interface{}{"age": 18},
+//		Variable: "n",
+//		Property: "name",
+//		Return:   "n.name AS name, n.age AS age",
+//	}
+//	page, err := p.Page(db, cursor, 50)
+type Paginator struct {
+	// The query matching the rows to paginate, without a RETURN clause.
+	Base   string
+	Params map[string]interface{}
+	// The variable of the node or relationship to sort by, which must be bound by the base query.
+	Variable string
+	// The property to sort by. It should be indexed and must not be null.
+	// Strings, booleans, numbers, temporal values and durations may be sorted by.
+	// Rows with equal values are ordered by the id of the variable, or by its element id on neo4j 5.
+	Property   string
+	Descending bool
+	// The expressions to return for each row.
+	Return string
+	// The size of a page when none is requested. Defaults to 20.
+	DefaultPageSize int
+	// The largest page which may be requested. Defaults to 100.
+	MaxPageSize int
+	// Count the total number of rows matched by the base query with each page.
+	CountTotal bool
+}
+
+// The sort value and id of the last row of a page.
+// Values are kept in their cypher string form along with their type, and converted back by the server,
+// so that numbers keep their precision and temporal values compare with the property.
+type cursor struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+	// The id, or the element id when ElementID is set.
+	ID        string `json:"i"`
+	ElementID bool   `json:"e,omitempty"`
+}
+
+// The cypher functions which convert the string form of a value back into a value, by the type of the value.
+var conversions = map[string]string{
+	"string":        "",
+	"boolean":       "toBoolean",
+	"integer":       "toInteger",
+	"float":         "toFloat",
+	"date":          "date",
+	"localdatetime": "localdatetime",
+	"datetime":      "datetime",
+	"localtime":     "localtime",
+	"time":          "time",
+	"duration":      "duration",
+}
+
+// Get the page of rows following the cursor, or the first page if the cursor is empty.
+// The size is limited to the range [1, MaxPageSize], and the default page size is used when it is 0.
+func (p *Paginator) Page(runner cypher.Runner, after string, size int) (*Page, error) {
+	size = p.pageSize(size)
+	elementIDs, err := usesElementIDs(runner)
+	if err != nil {
+		return nil, err
+	}
+	params := make(map[string]interface{}, len(p.Params)+3)
+	for k, v := range p.Params {
+		params[k] = v
+	}
+	params["__limit"] = size + 1
+	var c *cursor
+	if after != "" {
+		decoded, err := decodeCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		if decoded.ElementID != elementIDs {
+			return nil, ErrInvalidCursor
+		}
+		c = &decoded
+		params["__cursor_value"] = c.Value
+		params["__cursor_id"] = c.ID
+	}
+
+	result := runner.Run(p.statement(c, elementIDs), params)
+	rows, err := cypher.Collect(result)
+	if err != nil {
+		return nil, errors.WithMessage(err, "cypher/paginate: failed to get page")
+	}
+	stats, err := result.Consume()
+	if err != nil {
+		return nil, errors.WithMessage(err, "cypher/paginate: failed to get page")
+	}
+	columns := result.Columns()
+	page := &Page{
+		columns: columns[:len(columns)-3],
+		stats:   stats,
+		total:   -1,
+	}
+	if len(rows) > size {
+		rows = rows[:size]
+		page.hasNext = true
+	}
+	page.rows = make([]cypher.Row, len(rows))
+	for i, r := range rows {
+		page.rows[i] = &pageRow{row: r, keys: page.columns}
+	}
+	if page.hasNext {
+		last := rows[len(rows)-1]
+		value, _ := last.GetAt(len(columns) - 3).(string)
+		isString, _ := last.GetAt(len(columns) - 2).(bool)
+		next := cursor{Type: typeOf(value, isString), Value: value, ElementID: elementIDs}
+		if next.Type == "" {
+			return nil, errors.New("cypher/paginate: cannot paginate by the value " + value + " of " + p.Property)
+		}
+		next.ID, _ = last.GetAt(len(columns) - 1).(string)
+		if page.next, err = encodeCursor(next); err != nil {
+			return nil, err
+		}
+	}
+	if p.CountTotal {
+		row, err := cypher.Single(runner.Run(p.Base+"\nRETURN count(*) AS total", p.Params))
+		if err != nil {
+			return nil, errors.WithMessage(err, "cypher/paginate: failed to count total")
+		}
+		if row != nil {
+			total, _ := cypher.AsInt64(row.GetAt(0))
+			page.total = int(total)
+		}
+	}
+	return page, nil
+}
+
+func (p *Paginator) pageSize(size int) int {
+	max := p.MaxPageSize
+	if max <= 0 {
+		max = 100
+	}
+	if size <= 0 {
+		size = p.DefaultPageSize
+		if size <= 0 {
+			size = 20
+		}
+	}
+	if size > max {
+		size = max
+	}
+	return size
+}
+
+// Build the statement for the page following the cursor, or for the first page if it is nil.
+func (p *Paginator) statement(c *cursor, elementIDs bool) string {
+	v := p.Variable
+	prop := v + ".`" + strings.ReplaceAll(p.Property, "`", "``") + "`"
+	id, idString, cursorID := "id("+v+")", "toString(id("+v+"))", "toInteger($__cursor_id)"
+	if elementIDs {
+		id, idString, cursorID = "elementId("+v+")", "elementId("+v+")", "$__cursor_id"
+	}
+	cmp, order := ">", ""
+	if p.Descending {
+		cmp, order = "<", " DESC"
+	}
+	where := ""
+	if c != nil {
+		value := conversions[c.Type] + "($__cursor_value)"
+		where = "WHERE " + prop + " " + cmp + " " + value + " OR (" +
+			prop + " = " + value + " AND " + id + " " + cmp + " " + cursorID + ")\n"
+	}
+	return p.Base + "\nWITH *\n" + where +
+		"RETURN " + p.Return + ", toString(" + prop + ") AS __cursor_value, " +
+		prop + " = toString(" + prop + ") AS __cursor_string, " + idString + " AS __cursor_id\n" +
+		"ORDER BY " + prop + order + ", " + id + order + "\n" +
+		"LIMIT $__limit"
+}
+
+// Identify the type of a value from its cypher string form, and whether the value is itself a string.
+// Returns an empty string for values which cannot be converted back, such as points and lists.
+func typeOf(s string, isString bool) string {
+	if isString {
+		return "string"
+	}
+	if s == "true" || s == "false" {
+		return "boolean"
+	}
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return "integer"
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return "float"
+	}
+	if s == "" || strings.HasPrefix(s, "point(") || strings.ContainsAny(s[:1], "[{") {
+		return ""
+	}
+	return httpapi.TemporalType(s)
+}
+
+// Whether the server is neo4j 5 or later, where rows are ordered by element id as id() is deprecated.
+// The version is found from the server info of a DB, or asked of the server otherwise, such as for a transaction.
+func usesElementIDs(runner cypher.Runner) (bool, error) {
+	var version string
+	if db, ok := runner.(interface{ ServerInfo() cypher.ServerInfo }); ok {
+		version = db.ServerInfo().Version
+	}
+	if version == "" {
+		row, err := cypher.Single(runner.Run("CALL dbms.components() YIELD name, versions "+
+			"WHERE name = 'Neo4j Kernel' RETURN versions[0] AS version", nil))
+		if err != nil {
+			return false, errors.WithMessage(err, "cypher/paginate: failed to get the server version")
+		}
+		if row != nil {
+			version, _ = row.GetAt(0).(string)
+		}
+	}
+	major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	return err == nil && major >= 5, nil
+}
+
+func encodeCursor(c cursor) (string, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", errors.WithMessage(err, "cypher/paginate: failed to encode cursor")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err = json.Unmarshal(b, &c); err != nil || c.ID == "" {
+		return c, ErrInvalidCursor
+	}
+	if _, ok := conversions[c.Type]; !ok {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
@@ -0,0 +1,251 @@
+package paginate
+
+import (
+	"github.com/tjbrockmeyer/cypher"
+	"github.com/tjbrockmeyer/cypher/internal/cyphertest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+var people = &Paginator{
+	Base:     "MATCH (n:Person) WHERE n.age >= $age",
+	Params:   map[string]interface{}{"age": 18},
+	Variable: "n",
+	Property: "na`me",
+	Return:   "n.name AS name",
+}
+
+func TestStatement(t *testing.T) {
+	descending := *people
+	descending.Descending = true
+	tests := []struct {
+		name       string
+		p          *Paginator
+		c          *cursor
+		elementIDs bool
+		want       string
+	}{
+		{"first page", people, nil, false, "MATCH (n:Person) WHERE n.age >= $age\n" +
+			"WITH *\n" +
+			"RETURN n.name AS name, toString(n.`na``me`) AS __cursor_value, n.`na``me` = toString(n.`na``me`) AS __cursor_string, " +
+			"toString(id(n)) AS __cursor_id\n" +
+			"ORDER BY n.`na``me`, id(n)\n" +
+			"LIMIT $__limit"},
+		{"after an integer", people, &cursor{Type: "integer", Value: "3", ID: "7"}, false, "MATCH (n:Person) WHERE n.age >= $age\n" +
+			"WITH *\n" +
+			"WHERE n.`na``me` > toInteger($__cursor_value) OR " +
+			"(n.`na``me` = toInteger($__cursor_value) AND id(n) > toInteger($__cursor_id))\n" +
+			"RETURN n.name AS name, toString(n.`na``me`) AS __cursor_value, n.`na``me` = toString(n.`na``me`) AS __cursor_string, " +
+			"toString(id(n)) AS __cursor_id\n" +
+			"ORDER BY n.`na``me`, id(n)\n" +
+			"LIMIT $__limit"},
+		{"descending after a string by element id", &descending, &cursor{Type: "string", Value: "a", ID: "4:x:7", ElementID: true}, true,
+			"MATCH (n:Person) WHERE n.age >= $age\n" +
+				"WITH *\n" +
+				"WHERE n.`na``me` < ($__cursor_value) OR (n.`na``me` = ($__cursor_value) AND elementId(n) < $__cursor_id)\n" +
+				"RETURN n.name AS name, toString(n.`na``me`) AS __cursor_value, n.`na``me` = toString(n.`na``me`) AS __cursor_string, " +
+				"elementId(n) AS __cursor_id\n" +
+				"ORDER BY n.`na``me` DESC, elementId(n) DESC\n" +
+				"LIMIT $__limit"},
+		{"after a datetime", people, &cursor{Type: "datetime", Value: "2024-01-02T03:04:05Z", ID: "7"}, false,
+			"MATCH (n:Person) WHERE n.age >= $age\n" +
+				"WITH *\n" +
+				"WHERE n.`na``me` > datetime($__cursor_value) OR " +
+				"(n.`na``me` = datetime($__cursor_value) AND id(n) > toInteger($__cursor_id))\n" +
+				"RETURN n.name AS name, toString(n.`na``me`) AS __cursor_value, n.`na``me` = toString(n.`na``me`) AS __cursor_string, " +
+				"toString(id(n)) AS __cursor_id\n" +
+				"ORDER BY n.`na``me`, id(n)\n" +
+				"LIMIT $__limit"},
+	}
+	for _, test := range tests {
+		if got := test.p.statement(test.c, test.elementIDs); got != test.want {
+			t.Errorf("%s: built\n%s\nexpected\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestTypeOf(t *testing.T) {
+	tests := []struct {
+		value    string
+		isString bool
+		want     string
+	}{
+		{"12", true, "string"},
+		{"", true, "string"},
+		{"true", false, "boolean"},
+		{"false", false, "boolean"},
+		{"-12", false, "integer"},
+		{"9223372036854775807", false, "integer"},
+		{"1.5", false, "float"},
+		{"1.0E10", false, "float"},
+		{"NaN", false, "float"},
+		{"2024-01-02", false, "date"},
+		{"-0001-01-02", false, "date"},
+		{"03:04:05", false, "localtime"},
+		{"03:04:05.123", false, "localtime"},
+		{"03:04:05Z", false, "time"},
+		{"03:04:05+01:00", false, "time"},
+		{"2024-01-02T03:04:05", false, "localdatetime"},
+		{"2024-01-02T03:04:05.000000001", false, "localdatetime"},
+		{"2024-01-02T03:04:05Z", false, "datetime"},
+		{"2024-01-02T03:04:05-05:00", false, "datetime"},
+		{"2024-01-02T03:04:05+01:00[Europe/Paris]", false, "datetime"},
+		{"P1Y2M3DT4H", false, "duration"},
+		{"PT-1S", false, "duration"},
+		{"point({srid:7203, x:1.0, y:2.0})", false, ""},
+		{"[1, 2]", false, ""},
+		{"{a: 1}", false, ""},
+		{"", false, ""},
+	}
+	for _, test := range tests {
+		if got := typeOf(test.value, test.isString); got != test.want {
+			t.Errorf("typeOf(%q, %v) = %q, expected %q", test.value, test.isString, got, test.want)
+		}
+	}
+}
+
+func TestCursor(t *testing.T) {
+	for _, c := range []cursor{
+		{Type: "integer", Value: "9223372036854775807", ID: "12"},
+		{Type: "float", Value: "0.1", ID: "12"},
+		{Type: "datetime", Value: "2024-01-02T03:04:05+01:00[Europe/Paris]", ID: "4:b1f:12", ElementID: true},
+		{Type: "string", Value: "", ID: "12"},
+	} {
+		s, err := encodeCursor(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := decodeCursor(s)
+		if err != nil {
+			t.Errorf("decoding the cursor of %+v failed: %v", c, err)
+		} else if decoded != c {
+			t.Errorf("the cursor of %+v was decoded as %+v", c, decoded)
+		}
+	}
+	unknownType, _ := encodeCursor(cursor{Type: "point", Value: "point({x:1, y:2})", ID: "1"})
+	noID, _ := encodeCursor(cursor{Type: "integer", Value: "1"})
+	for _, s := range []string{"not base64!", "bm90IGpzb24", unknownType, noID} {
+		if _, err := decodeCursor(s); err != ErrInvalidCursor {
+			t.Errorf("decoding %q returned %v, expected ErrInvalidCursor", s, err)
+		}
+	}
+}
+
+// Answer page statements with the rows numbered after the cursor, up to the limit, out of total rows.
+func pages(total int) func(string, map[string]interface{}) *cyphertest.Result {
+	return func(statement string, params map[string]interface{}) *cyphertest.Result {
+		start := 1
+		if v, ok := params["__cursor_value"].(string); ok {
+			n, _ := strconv.Atoi(v)
+			start = n + 1
+		}
+		var rows [][]interface{}
+		for n := start; n <= total && len(rows) < params["__limit"].(int); n++ {
+			s := strconv.Itoa(n)
+			rows = append(rows, []interface{}{"p" + s, s, false, "4:db:" + s})
+		}
+		return cyphertest.NewResult([]string{"name", "__cursor_value", "__cursor_string", "__cursor_id"}, rows...)
+	}
+}
+
+func TestPage(t *testing.T) {
+	db := &cyphertest.DB{Version: "5.12.0", Respond: pages(5)}
+	p := *people
+	p.Property = "n"
+	var names []interface{}
+	after := ""
+	for i := 0; i < 3; i++ {
+		page, err := p.Page(db, after, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(page.Columns(), []string{"name"}) {
+			t.Fatalf("the columns of the page are %v", page.Columns())
+		}
+		rows, err := cypher.Collect(page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range rows {
+			if row.Len() != 1 {
+				t.Fatalf("the cursor columns were not hidden: %v", row.Keys())
+			}
+			names = append(names, row.Get("name"))
+		}
+		if page.HasNext() != (i < 2) || (page.NextCursor() != "") != (i < 2) {
+			t.Fatalf("page %d has a next page: %v", i, page.HasNext())
+		}
+		after = page.NextCursor()
+	}
+	if want := []interface{}{"p1", "p2", "p3", "p4", "p5"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("paged through %v, expected %v", names, want)
+	}
+	runs := db.Runs()
+	if len(runs) != 3 {
+		t.Fatalf("ran %d statements, expected 3", len(runs))
+	}
+	if want := map[string]interface{}{"age": 18, "__limit": 3, "__cursor_value": "2", "__cursor_id": "4:db:2"}; !reflect.DeepEqual(runs[1].Params, want) {
+		t.Errorf("the params of the second page are %v, expected %v", runs[1].Params, want)
+	}
+	if p.Params["__limit"] != nil {
+		t.Error("the params of the paginator were modified")
+	}
+
+	// A cursor of a server which orders by id is not valid for a server which orders by element id.
+	old, _ := encodeCursor(cursor{Type: "integer", Value: "2", ID: "2"})
+	if _, err := p.Page(db, old, 2); err != ErrInvalidCursor {
+		t.Errorf("a cursor ordered by id was accepted by neo4j 5: %v", err)
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	tests := []struct {
+		p          Paginator
+		size, want int
+	}{
+		{Paginator{}, 0, 20},
+		{Paginator{}, 500, 100},
+		{Paginator{DefaultPageSize: 5}, 0, 5},
+		{Paginator{MaxPageSize: 10}, 11, 10},
+		{Paginator{}, -1, 20},
+		{Paginator{}, 7, 7},
+	}
+	for _, test := range tests {
+		if got := test.p.pageSize(test.size); got != test.want {
+			t.Errorf("the size of a page of %d with %+v is %d, expected %d", test.size, test.p, got, test.want)
+		}
+	}
+}
+
+func TestUsesElementIDs(t *testing.T) {
+	// A transaction does not report the server info, so the version is asked of the server.
+	tests := []struct {
+		version, components string
+		db, tx              bool
+	}{
+		{"5.0.0", "5.0.0", true, true},
+		{"4.4.12", "4.4.12", false, false},
+		{"", "5.26.0", true, true},
+		{"", "4.4.0", false, false},
+		{"", "", false, false},
+	}
+	for _, test := range tests {
+		db := &cyphertest.DB{Version: test.version, Respond: func(string, map[string]interface{}) *cyphertest.Result {
+			if test.components == "" {
+				return nil
+			}
+			return cyphertest.NewResult([]string{"version"}, []interface{}{test.components})
+		}}
+		tx, err := db.TX()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := usesElementIDs(db); err != nil || got != test.db {
+			t.Errorf("usesElementIDs of a DB of version %q = %v, %v", test.version, got, err)
+		}
+		if got, err := usesElementIDs(tx); err != nil || got != test.tx {
+			t.Errorf("usesElementIDs of a transaction of version %q = %v, %v", test.components, got, err)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"strconv"
+)
+
+type conn struct {
+	db cypher.DB
+	tx cypher.Transaction
+	// Set when the database belongs to this connection alone, rather than being shared by a connector.
+	ownsDB bool
+}
+
+// Returns the runner which statements should currently be run on.
+func (c *conn) runner() cypher.Runner {
+	if c.tx != nil {
+		return c.tx
+	}
+	return c.db
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+// Close the connection, rolling back its transaction if one is in progress.
+func (c *conn) Close() error {
+	var err error
+	if c.tx != nil {
+		err = c.tx.Rollback()
+		c.tx = nil
+	}
+	if c.ownsDB {
+		if closeErr := c.db.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.tx != nil {
+		return nil, errors.New("cypher/sqldriver: a transaction is already in progress")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("cypher/sqldriver: read-only transactions are not supported")
+	}
+	tx, err := c.db.TX()
+	if err != nil {
+		return nil, err
+	}
+	c.tx = tx
+	return &transaction{conn: c}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result, err := runContext(ctx, c.runner(), query, bindParams(args))
+	if err != nil {
+		return nil, err
+	}
+	stats, err := result.Consume()
+	if err != nil {
+		return nil, err
+	}
+	return execResult{stats: stats}, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result, err := runContext(ctx, c.runner(), query, bindParams(args))
+	if err != nil {
+		return nil, err
+	}
+	return &rows{ctx: ctx, result: result}, nil
+}
+
+// Run a statement until the context is done, after which the statement is left to finish, and is consumed,
+// in the background.
+func runContext(ctx context.Context, runner cypher.Runner, query string, params map[string]interface{}) (cypher.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	results := make(chan cypher.Result, 1)
+	go func() {
+		results <- runner.Run(query, params)
+	}()
+	select {
+	case result := <-results:
+		if err := result.Err(); err != nil {
+			_, _ = result.Consume()
+			return nil, err
+		}
+		return result, nil
+	case <-ctx.Done():
+		go func() {
+			_, _ = (<-results).Consume()
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// Accept any parameter value - the cypher driver is responsible for encoding it.
+func (c *conn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+// Convert the arguments into cypher parameters.
+// Named arguments are bound by name, positional arguments by their ordinal.
+func bindParams(args []driver.NamedValue) map[string]interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+	params := make(map[string]interface{}, len(args))
+	for _, arg := range args {
+		if arg.Name != "" {
+			params[arg.Name] = arg.Value
+		} else {
+			params[strconv.Itoa(arg.Ordinal)] = arg.Value
+		}
+	}
+	return params
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	return s.conn.CheckNamedValue(nv)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
+
+type transaction struct {
+	conn *conn
+}
+
+func (tx *transaction) Commit() error {
+	defer tx.done()
+	return tx.conn.tx.Commit()
+}
+
+func (tx *transaction) Rollback() error {
+	defer tx.done()
+	return tx.conn.tx.Rollback()
+}
+
+func (tx *transaction) done() {
+	tx.conn.tx = nil
+}
@@ -0,0 +1,116 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"github.com/tjbrockmeyer/cypher/internal/cyphertest"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestBindParams(t *testing.T) {
+	tests := []struct {
+		args []driver.NamedValue
+		want map[string]interface{}
+	}{
+		{nil, nil},
+		{[]driver.NamedValue{{Ordinal: 1, Value: int64(1)}, {Ordinal: 2, Value: "a"}},
+			map[string]interface{}{"1": int64(1), "2": "a"}},
+		{[]driver.NamedValue{{Name: "name", Ordinal: 1, Value: "a"}, {Ordinal: 2, Value: nil}},
+			map[string]interface{}{"name": "a", "2": nil}},
+	}
+	for _, test := range tests {
+		if got := bindParams(test.args); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("bindParams(%v) = %v, expected %v", test.args, got, test.want)
+		}
+	}
+}
+
+func TestContextDone(t *testing.T) {
+	db := new(cyphertest.DB)
+	c := &conn{db: db}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.ExecContext(ctx, "CREATE (:Node)", nil); err != context.Canceled {
+		t.Errorf("ExecContext returned %v, expected %v", err, context.Canceled)
+	}
+	if _, err := c.QueryContext(ctx, "RETURN 1", nil); err != context.Canceled {
+		t.Errorf("QueryContext returned %v, expected %v", err, context.Canceled)
+	}
+	if _, err := c.BeginTx(ctx, driver.TxOptions{}); err != context.Canceled {
+		t.Errorf("BeginTx returned %v, expected %v", err, context.Canceled)
+	}
+	if runs := db.Runs(); len(runs) != 0 {
+		t.Errorf("statements were run with a done context: %v", runs)
+	}
+}
+
+func TestContextDoneWhileReading(t *testing.T) {
+	result := cyphertest.NewResult([]string{"n"}, []interface{}{int64(1)}, []interface{}{int64(2)})
+	c := &conn{db: &cyphertest.DB{Respond: func(string, map[string]interface{}) *cyphertest.Result {
+		return result
+	}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r, err := c.QueryContext(ctx, "UNWIND [1, 2] AS n RETURN n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := make([]driver.Value, 1)
+	if err = r.Next(dest); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err = r.Next(dest); err != context.Canceled {
+		t.Errorf("Next returned %v after the context was done, expected %v", err, context.Canceled)
+	}
+	if err = r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !result.Consumed() {
+		t.Error("the result was not consumed when the rows were closed")
+	}
+	if err = r.Next(dest); err != context.Canceled && err != io.EOF {
+		t.Errorf("Next returned %v after the rows were closed", err)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	db := new(cyphertest.DB)
+	c := &conn{db: db}
+	tx, err := c.BeginTx(context.Background(), driver.TxOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.ExecContext(context.Background(), "CREATE (:Node)", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.BeginTx(context.Background(), driver.TxOptions{}); err == nil {
+		t.Error("a second transaction was begun while the first was in progress")
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.ExecContext(context.Background(), "CREATE (:Node)", nil); err != nil {
+		t.Fatal(err)
+	}
+	runs := db.Runs()
+	if len(runs) != 2 || runs[0].TX != 1 || runs[1].TX != 0 {
+		t.Errorf("statements were not run in the transaction while it was in progress: %+v", runs)
+	}
+
+	// Closing a connection rolls back its transaction, but does not close the database it shares.
+	if _, err = c.BeginTx(context.Background(), driver.TxOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if commits, rollbacks := db.Ended(); commits != 1 || rollbacks != 1 {
+		t.Errorf("%d transactions were committed and %d rolled back, expected 1 and 1", commits, rollbacks)
+	}
+	if db.Closed() {
+		t.Error("closing the connection closed the shared database")
+	}
+}
@@ -0,0 +1,85 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"io"
+	"time"
+)
+
+type rows struct {
+	// Rows are no longer read once the context is done.
+	ctx    context.Context
+	result cypher.Result
+	closed bool
+}
+
+func (r *rows) Columns() []string {
+	return r.result.Columns()
+}
+
+func (r *rows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	_, err := r.result.Consume()
+	return err
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	if !r.result.NextRow() {
+		r.closed = true
+		if err := r.result.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	for i, value := range r.result.GetRow().Values() {
+		v, err := toDriverValue(value)
+		if err != nil {
+			return errors.WithMessagef(err, "cypher/sqldriver: failed to convert column %v", i)
+		}
+		dest[i] = v
+	}
+	return nil
+}
+
+// Convert a value from a cypher row into a value acceptable to database/sql.
+// Composite values such as maps, lists and nodes are encoded as json.
+func toDriverValue(value interface{}) (driver.Value, error) {
+	switch v := value.(type) {
+	case nil, int64, float64, bool, []byte, string, time.Time:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	default:
+		return json.Marshal(v)
+	}
+}
+
+type execResult struct {
+	stats cypher.Stats
+}
+
+func (r execResult) LastInsertId() (int64, error) {
+	return 0, errors.New("cypher/sqldriver: LastInsertId is not supported")
+}
+
+// The number of entities and properties changed by the statement.
+func (r execResult) RowsAffected() (int64, error) {
+	s := r.stats
+	return int64(s.NodesCreated() + s.NodesDeleted() + s.RelationshipsCreated() + s.RelationshipDeleted() +
+		s.PropertiesSet() + s.LabelsAdded() + s.LabelsRemoved()), nil
+}
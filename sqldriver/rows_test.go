@@ -0,0 +1,104 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tjbrockmeyer/cypher"
+	"github.com/tjbrockmeyer/cypher/internal/cyphertest"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestToDriverValue(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value interface{}
+		want  driver.Value
+	}{
+		{nil, nil},
+		{int64(1), int64(1)},
+		{1, int64(1)},
+		{1.5, 1.5},
+		{true, true},
+		{"a", "a"},
+		{[]byte("a"), []byte("a")},
+		{at, at},
+		{json.Number("9007199254740993"), int64(9007199254740993)},
+		{json.Number("1.5"), 1.5},
+		{[]interface{}{1.0, "a"}, []byte(`[1,"a"]`)},
+		{map[string]interface{}{"a": 1.0}, []byte(`{"a":1}`)},
+		{cypher.Node{ID: 1, Labels: []string{"N"}, Properties: map[string]interface{}{"a": 1.0}},
+			mustMarshal(t, cypher.Node{ID: 1, Labels: []string{"N"}, Properties: map[string]interface{}{"a": 1.0}})},
+	}
+	for _, test := range tests {
+		got, err := toDriverValue(test.value)
+		if err != nil {
+			t.Errorf("toDriverValue(%#v) returned the error %v", test.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("toDriverValue(%#v) = %#v, expected %#v", test.value, got, test.want)
+		}
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestRows(t *testing.T) {
+	result := cyphertest.NewResult([]string{"name", "age", "tags"},
+		[]interface{}{"a", 1, []interface{}{"x"}},
+		[]interface{}{"b", json.Number("2"), nil})
+	r := &rows{ctx: context.Background(), result: result}
+	if columns := r.Columns(); !reflect.DeepEqual(columns, []string{"name", "age", "tags"}) {
+		t.Errorf("the columns are %v", columns)
+	}
+	want := [][]driver.Value{{"a", int64(1), []byte(`["x"]`)}, {"b", int64(2), nil}}
+	dest := make([]driver.Value, 3)
+	for i, values := range want {
+		if err := r.Next(dest); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(dest, values) {
+			t.Errorf("row %d is %#v, expected %#v", i, dest, values)
+		}
+	}
+	if err := r.Next(dest); err != io.EOF {
+		t.Errorf("Next returned %v after the last row, expected io.EOF", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRowsError(t *testing.T) {
+	result := cyphertest.NewResult([]string{"n"}, []interface{}{1})
+	result.Error = errors.New("failed")
+	r := &rows{ctx: context.Background(), result: result}
+	dest := make([]driver.Value, 1)
+	if err := r.Next(dest); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Next(dest); err != result.Error {
+		t.Errorf("Next returned %v after the last row, expected the error of the result", err)
+	}
+}
+
+func TestExecResult(t *testing.T) {
+	r := execResult{stats: cyphertest.Stats{"nodes_created": 2, "properties_set": 3, "relationships_created": 1}}
+	if n, err := r.RowsAffected(); err != nil || n != 6 {
+		t.Errorf("RowsAffected() = %d, %v, expected 6", n, err)
+	}
+	if _, err := r.LastInsertId(); err == nil {
+		t.Error("LastInsertId did not fail")
+	}
+}
@@ -0,0 +1,294 @@
+package cypher
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"reflect"
+	"strings"
+)
+
+// Valuer is implemented by types which convert themselves into a value accepted as a parameter,
+// such as a string, number, bool, list or map, when parameters are encoded by a driver.
+type Valuer interface {
+	CypherValue() (interface{}, error)
+}
+
+// Scanner is implemented by types which populate themselves from a value of a row, as represented by the driver.
+// UnmarshalRow and UnmarshalRows scan the columns of Scanner fields at the top level of a struct,
+// or the value of a row with a single column into a Scanner, and ColumnAs scans each value of its column.
+type Scanner interface {
+	ScanCypher(value interface{}) error
+}
+
+var scannerType = reflect.TypeOf((*Scanner)(nil)).Elem()
+var valuerType = reflect.TypeOf((*Valuer)(nil)).Elem()
+
+// Convert parameters for encoding, replacing Valuers with their values, including those within lists and maps.
+// The given map is not modified.
+func ConvertParams(params map[string]interface{}) (map[string]interface{}, error) {
+	if params == nil {
+		return nil, nil
+	}
+	converted := make(map[string]interface{}, len(params))
+	for name, value := range params {
+		v, err := ConvertValue(value)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to convert parameter "+name)
+		}
+		converted[name] = v
+	}
+	return converted, nil
+}
+
+// Convert a value for encoding as a parameter, replacing Valuers with their values, including those within
+// lists and maps. Lists and maps which may hold Valuers are copied into []interface{} and map[string]interface{}.
+func ConvertValue(value interface{}) (interface{}, error) {
+	if v, ok := value.(Valuer); ok {
+		if rv := reflect.ValueOf(value); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil, nil
+		}
+		converted, err := v.CypherValue()
+		if err != nil {
+			return nil, err
+		}
+		return ConvertValue(converted)
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if !mayHoldValuers(rv.Type().Elem()) || (rv.Kind() == reflect.Slice && rv.IsNil()) {
+			return value, nil
+		}
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			item, err := ConvertValue(rv.Index(i).Interface())
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed to convert list item %v", i)
+			}
+			list[i] = item
+		}
+		return list, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String || !mayHoldValuers(rv.Type().Elem()) || rv.IsNil() {
+			return value, nil
+		}
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			entry, err := ConvertValue(iter.Value().Interface())
+			if err != nil {
+				return nil, errors.WithMessage(err, "failed to convert map entry "+key)
+			}
+			m[key] = entry
+		}
+		return m, nil
+	}
+	return value, nil
+}
+
+func mayHoldValuers(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return t.Implements(valuerType)
+}
+
+// A Scanner field of a struct, by the column which it is scanned from.
+type scannerField struct {
+	column string
+	field  reflect.Value
+}
+
+// Find the Scanner fields at the top level of the struct pointed to by v.
+// Fields are named as by encoding/json.
+func scannerFields(v reflect.Value) []scannerField {
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	s := v.Elem()
+	t := s.Type()
+	var fields []scannerField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		if reflect.PointerTo(f.Type).Implements(scannerType) ||
+			(f.Type.Kind() == reflect.Ptr && f.Type.Implements(scannerType)) {
+			fields = append(fields, scannerField{column: name, field: s.Field(i)})
+		}
+	}
+	return fields
+}
+
+// Whether the elements of a slice of type t, or pointers to them, have Scanner fields or are Scanners.
+func hasScanners(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(scannerType) {
+		return true
+	}
+	return t.Kind() == reflect.Struct && len(scannerFields(reflect.New(t))) > 0
+}
+
+// Scan a value into a Scanner field, allocating the field if it is a pointer.
+func scanField(field reflect.Value, value interface{}) error {
+	if field.Kind() == reflect.Ptr && !reflect.PointerTo(field.Type()).Implements(scannerType) {
+		if value == nil {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return field.Interface().(Scanner).ScanCypher(value)
+	}
+	return field.Addr().Interface().(Scanner).ScanCypher(value)
+}
+
+// Unmarshal a row into a struct with Scanner fields.
+// The other columns are unmarshaled as json, matching field names as encoding/json does.
+func unmarshalScanners(row Row, v interface{}, fields []scannerField) error {
+	values := row.AsMap()
+	scans := make([]func() error, 0, len(fields))
+	for _, f := range fields {
+		f := f
+		column, ok := f.column, false
+		if _, ok = values[column]; !ok {
+			for key := range values {
+				if strings.EqualFold(key, f.column) {
+					column, ok = key, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		value := values[column]
+		delete(values, column)
+		scans = append(scans, func() error {
+			return errors.WithMessage(scanField(f.field, value), "failed to scan column "+column)
+		})
+	}
+	mode := NumberModeOf(row)
+	b, err := marshalValues(values, mode)
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal row into json")
+	}
+	if err = unmarshalNumbers(b, v, mode); err != nil {
+		return errors.WithMessage(err, "failed to unmarshal row into struct")
+	}
+	for _, scan := range scans {
+		if err = scan(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NullString is a string which may be null, both as a parameter and in a row.
+type NullString struct {
+	String string
+	// False when the value is null.
+	Valid bool
+}
+
+func (n NullString) CypherValue() (interface{}, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.String, nil
+}
+
+func (n *NullString) ScanCypher(value interface{}) error {
+	if value == nil {
+		*n = NullString{}
+		return nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return errors.Errorf("cypher: cannot scan %T into NullString", value)
+	}
+	*n = NullString{String: s, Valid: true}
+	return nil
+}
+
+func (n NullString) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.String)
+}
+
+func (n *NullString) UnmarshalJSON(b []byte) error {
+	var s *string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*n = NullString{}
+	if s != nil {
+		*n = NullString{String: *s, Valid: true}
+	}
+	return nil
+}
+
+// NullInt64 is an int64 which may be null, both as a parameter and in a row.
+// Numbers of any NumberMode are scanned, if they are whole.
+type NullInt64 struct {
+	Int64 int64
+	// False when the value is null.
+	Valid bool
+}
+
+func (n NullInt64) CypherValue() (interface{}, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Int64, nil
+}
+
+func (n *NullInt64) ScanCypher(value interface{}) error {
+	if value == nil {
+		*n = NullInt64{}
+		return nil
+	}
+	i, ok := AsInt64(value)
+	if !ok {
+		return errors.Errorf("cypher: cannot scan %v (%T) into NullInt64", value, value)
+	}
+	*n = NullInt64{Int64: i, Valid: true}
+	return nil
+}
+
+func (n NullInt64) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Int64)
+}
+
+func (n *NullInt64) UnmarshalJSON(b []byte) error {
+	var i *int64
+	if err := json.Unmarshal(b, &i); err != nil {
+		return err
+	}
+	*n = NullInt64{}
+	if i != nil {
+		*n = NullInt64{Int64: *i, Valid: true}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package cypher
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// A row of the given values, whose numbers are represented in the given mode.
+type numberRow struct {
+	keys   []string
+	values []interface{}
+	mode   NumberMode
+}
+
+func (r *numberRow) GetAt(i int) interface{} {
+	return r.values[i]
+}
+
+func (r *numberRow) Get(n string) interface{} {
+	for i, key := range r.keys {
+		if key == n {
+			return r.values[i]
+		}
+	}
+	return nil
+}
+
+func (r *numberRow) Keys() []string {
+	return r.keys
+}
+
+func (r *numberRow) Values() []interface{} {
+	return r.values
+}
+
+func (r *numberRow) Len() int {
+	return len(r.values)
+}
+
+func (r *numberRow) AsMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(r.keys))
+	for i, key := range r.keys {
+		m[key] = r.values[i]
+	}
+	return m
+}
+
+func (r *numberRow) MetaAt(i int) interface{} {
+	return nil
+}
+
+func (r *numberRow) NumberMode() NumberMode {
+	return r.mode
+}
+
+func (r *numberRow) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.AsMap())
+}
+
+func TestUnmarshalRowNumbers(t *testing.T) {
+	type plain struct {
+		Value interface{} `json:"value"`
+	}
+	type scanned struct {
+		Name  NullString  `json:"name"`
+		Value interface{} `json:"value"`
+	}
+	tests := []struct {
+		value interface{}
+		mode  NumberMode
+		want  interface{}
+	}{
+		{2.0, NumberInt64, 2.0},
+		{2.5, NumberInt64, 2.5},
+		{int64(2), NumberInt64, int64(2)},
+		{[]interface{}{2.0, int64(2)}, NumberInt64, []interface{}{2.0, int64(2)}},
+		{map[string]interface{}{"x": 2.0}, NumberInt64, map[string]interface{}{"x": 2.0}},
+		{2.0, NumberFloat64, 2.0},
+	}
+	for _, test := range tests {
+		row := &numberRow{keys: []string{"name", "value"}, values: []interface{}{"a", test.value}, mode: test.mode}
+		var p plain
+		if err := UnmarshalRow(row, &p); err != nil {
+			t.Errorf("UnmarshalRow(%v) failed: %v", test.value, err)
+		} else if !reflect.DeepEqual(p.Value, test.want) {
+			t.Errorf("UnmarshalRow(%v) = %v (%T), expected %v (%T)", test.value, p.Value, p.Value, test.want, test.want)
+		}
+		var s scanned
+		if err := UnmarshalRow(row, &s); err != nil {
+			t.Errorf("UnmarshalRow(%v) next to a Scanner failed: %v", test.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(s.Value, test.want) {
+			t.Errorf("UnmarshalRow(%v) next to a Scanner = %v (%T), expected %v (%T)", test.value, s.Value, s.Value, test.want, test.want)
+		}
+		if s.Name != (NullString{String: "a", Valid: true}) {
+			t.Errorf("UnmarshalRow(%v) scanned %+v, expected a", test.value, s.Name)
+		}
+	}
+}